@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// GroupResults collapses results sharing the same version or the same folder in one result per group.
+// Primary file of a group is the one with the shortest path (e.g.: app-1.2.3.tgz in front of app-1.2.3.tgz.sha256),
+// group sha256 is computed from path and sha256 of each file to change when a file of the group changes.
+func (c Check) GroupResults(results []utils.SearchResult) ([]utils.SearchResult, error) {
+	return utils.GroupResults(c.cmd.Messager(), results, c.source.GroupBy, c.source.VersionStrategy)
+}
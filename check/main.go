@@ -3,15 +3,11 @@ package main
 import (
 	"errors"
 	"os"
-	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
 
 	chelper "github.com/ArthurHlt/go-concourse-helper"
 	"github.com/blang/semver"
-	"github.com/jfrog/jfrog-cli-core/v2/artifactory/commands/generic"
-	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
 	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
 	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
 	"github.com/orange-cloudfoundry/artifactory-resource/model"
@@ -19,24 +15,26 @@ import (
 )
 
 type SemverFile struct {
-	Path    string
-	Version semver.Version
+	Path     string
+	Sha256   string
+	Modified string
+	Files    []string
+	Version  semver.Version
 }
 
-const (
-	SEMVER_REGEX = `(v|-|_)?v?((?:\d+)\.?(?:\d+)?\.?(?:\d+)?(?:(?:-|\+)(?:dev|alpha|beta)(\.[0-9]+)?)?)`
-)
-
 type Check struct {
 	cmd        *chelper.CheckCommand
 	source     model.Source
+	version    model.Version
 	artdetails *config.ServerDetails
 	spec       *spec.SpecFiles
 }
 
 func main() {
+	msg, version := utils.NewMessager()
 	check := &Check{
-		cmd: chelper.NewCheckCommand(),
+		cmd:     chelper.NewCheckCommandWithMessager(msg),
+		version: version,
 	}
 	check.Run()
 }
@@ -44,11 +42,21 @@ func (c *Check) Run() {
 	cmd := c.cmd
 	msg := c.cmd.Messager()
 	c.source.Recursive = true
+	c.source.OrderBy = utils.ORDER_BY_MODIFIED
+	c.source.IncludePrereleases = true
 	err := cmd.Source(&c.source)
 
 	msg.FatalIf("Error when parsing source from concourse", err)
 	utils.OverrideLoggerArtifactory(c.source.LogLevel)
-	err = utils.CheckReqParamsWithPattern(c.source)
+	if c.source.Query != nil {
+		err = utils.CheckAqlQuery(c.source)
+	} else {
+		err = utils.CheckReqParamsWithPattern(c.source)
+	}
+	if err != nil {
+		msg.Fatal(err.Error())
+	}
+	err = utils.CheckVersionStrategy(c.source.VersionStrategy)
 	if err != nil {
 		msg.Fatal(err.Error())
 	}
@@ -68,109 +76,168 @@ func (c *Check) Run() {
 
 	origStdout := os.Stdout
 	os.Stdout = os.Stderr
-	results, err := c.Search()
+	var results []utils.SearchResult
+	if c.source.Query != nil {
+		results, err = c.SearchAql()
+	} else {
+		results, err = c.Search()
+	}
 	os.Stdout = origStdout
 	msg.FatalIf("Error when trying to find latest file", err)
+	if c.source.GroupBy != "" {
+		results, err = c.GroupResults(results)
+		msg.FatalIf("Error when grouping files", err)
+	}
 	versions, err := c.RetrieveVersions(results)
 	msg.FatalIf("Error when retrieving versions", err)
-	cmd.Send(versions)
+	msg.SendJsonResponse(versions)
 }
 
-func (c Check) Search() ([]artutils.SearchResult, error) {
-	res := []artutils.SearchResult{}
-	cmd := generic.NewSearchCommand()
-	cmd.
-		SetServerDetails(c.artdetails).
-		SetSpec(c.spec)
-
-	err := cmd.Run()
-	if err != nil {
-		return nil, err
-	}
-
-	reader := cmd.Result().Reader()
-	defer reader.Close()
-	_, err = reader.Length()
-	if err != nil {
-		return nil, err
-	}
-
-	for val := new(artutils.SearchResult); reader.NextRecord(val) == nil; val = new(artutils.SearchResult) {
-		res = append(res, *val)
-	}
+// Search runs search from jfrog client directly instead of jfrog cli search command for keeping sha256 of files.
+func (c Check) Search() ([]utils.SearchResult, error) {
+	return utils.Search(c.artdetails, c.spec)
+}
 
-	return res, nil
+// SearchAql runs the aql query set in source and gives files found.
+func (c Check) SearchAql() ([]utils.SearchResult, error) {
+	return utils.SearchAql(c.artdetails, *c.source.Query)
 }
 
-func (c Check) RetrieveVersions(results []artutils.SearchResult) ([]chelper.Version, error) {
-	versions := make([]chelper.Version, 0)
+func (c Check) RetrieveVersions(results []utils.SearchResult) ([]model.Version, error) {
+	versions := make([]model.Version, 0)
 	if len(results) == 0 {
 		return versions, nil
 	}
 	if c.source.Version == "" {
-		for _, file := range results {
-			versions = append(versions, chelper.Version{
-				BuildNumber: file.Path,
-			})
-		}
-		return versions, nil
+		return c.RetrieveVersionsOrdered(results)
 	}
 	semverPrevious := c.RetrieveSemverFilePrevious()
 	if semverPrevious.Path != "" {
-		versions = append(versions, chelper.Version{
-			BuildNumber: semverPrevious.Path,
-		})
+		versions = append(versions, c.RetrieveCurrentVersion(results))
 	}
 	rangeSem, err := c.RetrieveRange()
 	if err != nil {
 		return versions, err
 	}
 	semverFiles := c.ResultsToSemverFilesFiltered(results, rangeSem)
-	versions = append(versions, c.SemverFilesToVersions(semverFiles)...)
+	newVersions := c.SemverFilesToVersions(semverFiles)
+	if semverPrevious.Path == "" && len(newVersions) > 0 {
+		// first check only gives the latest version
+		newVersions = newVersions[len(newVersions)-1:]
+	}
+	versions = append(versions, newVersions...)
 	return versions, nil
 }
 
+// RetrieveVersionsOrdered gives current version and newer ones by ordering results on source order_by key,
+// only the latest version is given when there is no current version or when current version doesn't exist anymore.
+func (c Check) RetrieveVersionsOrdered(results []utils.SearchResult) ([]model.Version, error) {
+	versions := make([]model.Version, 0)
+	sortedResults, err := c.SortResults(results)
+	if err != nil {
+		return versions, err
+	}
+	if len(sortedResults) == 0 {
+		return versions, nil
+	}
+	startIndex := len(sortedResults) - 1
+	currentPath := c.version.FilePath()
+	for i, file := range sortedResults {
+		if currentPath != "" && file.Path == currentPath {
+			startIndex = i
+			break
+		}
+	}
+	for _, file := range sortedResults[startIndex:] {
+		versions = append(versions, c.ResultToVersion(file))
+	}
+	return versions, nil
+}
+
+// SortResults sorts results from oldest to newest on source order_by key.
+func (c Check) SortResults(results []utils.SearchResult) ([]utils.SearchResult, error) {
+	return utils.SortResults(c.cmd.Messager(), results, c.source.OrderBy, c.source.VersionStrategy)
+}
+
+// RetrieveCurrentVersion gives the current version as it is now in artifactory,
+// a file re-uploaded on the same path gives so a new version.
+func (c Check) RetrieveCurrentVersion(results []utils.SearchResult) model.Version {
+	for _, file := range results {
+		if file.Path == c.version.FilePath() {
+			return c.ResultToVersion(file)
+		}
+	}
+	return c.version
+}
+
+func (c Check) ResultToVersion(file utils.SearchResult) model.Version {
+	return utils.ResultToVersion(file, c.source.VersionStrategy)
+}
+
 func (c *Check) RetrieveRange() (semver.Range, error) {
-	rangeSem, err := semver.ParseRange(c.SanitizeVersion(c.source.Version))
+	rangeSem, err := semver.ParseRange(utils.SanitizeRange(c.source.Version))
 	if err != nil {
 		return nil, errors.New("Error when trying to create semver range: " + err.Error())
 	}
 	semverPrevious := c.RetrieveSemverFilePrevious()
 	if semverPrevious.Path != "" {
-		prevRangeSem, _ := semver.ParseRange(">" + semverPrevious.Version.String())
+		prevRangeSem := func(v semver.Version) bool {
+			return utils.SemverLT(semverPrevious.Version, v)
+		}
 		c.source.Version += " && >" + semverPrevious.Version.String()
 		rangeSem = rangeSem.AND(prevRangeSem)
 	}
 	return rangeSem, nil
 }
 
-func (c Check) SemverFilesToVersions(semverFiles []SemverFile) []chelper.Version {
+func (c Check) SemverFilesToVersions(semverFiles []SemverFile) []model.Version {
 	sort.Slice(semverFiles, func(i, j int) bool {
-		return semverFiles[i].Version.LT(semverFiles[j].Version)
+		return utils.SemverLT(semverFiles[i].Version, semverFiles[j].Version)
 	})
-	versions := make([]chelper.Version, 0)
+	versions := make([]model.Version, 0)
 	for _, fileSemver := range semverFiles {
-		versions = append(versions, chelper.Version{
-			BuildNumber: fileSemver.Path,
+		versions = append(versions, model.Version{
+			Path:     fileSemver.Path,
+			Sha256:   fileSemver.Sha256,
+			Modified: fileSemver.Modified,
+			Files:    strings.Join(fileSemver.Files, ","),
+			Semver:   fileSemver.Version.String(),
 		})
 	}
 	return versions
 }
 
 func (c Check) RetrieveSemverFilePrevious() SemverFile {
-	semverFile, _ := c.SemverFromPath(c.cmd.Version().BuildNumber)
+	if c.version.Semver != "" {
+		previousSemver, err := utils.ParseVersion(c.version.Semver)
+		if err == nil {
+			return SemverFile{
+				Path:    c.version.FilePath(),
+				Version: previousSemver,
+			}
+		}
+	}
+	semverFile, _ := c.SemverFromPath(c.version.FilePath())
 	return semverFile
 }
 
-func (c Check) ResultsToSemverFilesFiltered(results []artutils.SearchResult, rangeSem semver.Range) []SemverFile {
+func (c Check) ResultsToSemverFilesFiltered(results []utils.SearchResult, rangeSem semver.Range) []SemverFile {
 	msg := c.cmd.Messager()
 	semverFiles := make([]SemverFile, 0)
 	for _, file := range results {
-		semverFile, err := c.SemverFromPath(file.Path)
+		semverFile, err := c.SemverFromResult(file)
 		if err != nil {
 			msg.Logln("[yellow]Error[reset] for file '[blue]%s[reset]': %s [reset]", file.Path, err.Error())
 			continue
 		}
+		if !c.source.IncludePrereleases && len(semverFile.Version.Pre) > 0 {
+			msg.Logln(
+				"[cyan]Skipping[reset] file '[blue]%s[reset]' with version '[blue]%s[reset]' because it is a pre-release [reset]",
+				file.Path,
+				semverFile.Version.String(),
+			)
+			continue
+		}
 		if !rangeSem(semverFile.Version) {
 			msg.Logln(
 				"[cyan]Skipping[reset] file '[blue]%s[reset]' with version '[blue]%s[reset]' because it doesn't satisfy range '[blue]%s[reset]' [reset]",
@@ -181,42 +248,30 @@ func (c Check) ResultsToSemverFilesFiltered(results []artutils.SearchResult, ran
 			continue
 		}
 		msg.Logln("[blue]Found[reset] valid file '[blue]%s[reset]' in version '[blue]%s[reset]' [reset]", file.Path, semverFile.Version.String())
+		semverFile.Sha256 = file.Sha256
+		semverFile.Modified = file.Modified
+		semverFile.Files = file.Files
 		semverFiles = append(semverFiles, semverFile)
 	}
 	return semverFiles
 }
 
-func (c Check) SanitizeVersion(version string) string {
-	splitVersion := strings.Split(version, ".")
-	if len(splitVersion) == 1 {
-		version += ".0.0"
-	} else if len(splitVersion) == 2 {
-		version += ".0"
+func (c Check) SemverFromResult(file utils.SearchResult) (SemverFile, error) {
+	semverFound, err := utils.ExtractSemver(c.source.VersionStrategy, file.Path, file.Props)
+	if err != nil {
+		return SemverFile{}, err
 	}
-	return version
+	return SemverFile{
+		Path:    file.Path,
+		Version: semverFound,
+	}, nil
 }
 
 func (c Check) SemverFromPath(path string) (SemverFile, error) {
 	if path == "" {
 		return SemverFile{}, nil
 	}
-	pathSplitted := strings.Split(path, "/")
-	file := pathSplitted[len(pathSplitted)-1]
-	ext := filepath.Ext(file)
-	if ext != "" {
-		file = strings.TrimSuffix(file, ext)
-	}
-	r := regexp.MustCompile("(?i)" + SEMVER_REGEX)
-	allMatch := r.FindAllStringSubmatch(file, -1)
-	if len(allMatch) == 0 {
-		return SemverFile{}, errors.New("Cannot find any semver in file.")
-	}
-	if len(allMatch[0]) < 3 {
-		return SemverFile{}, errors.New("Cannot find any semver in file.")
-	}
-	versionFound := c.SanitizeVersion(allMatch[len(allMatch)-1][2])
-
-	semverFound, err := semver.Make(versionFound)
+	semverFound, err := utils.ExtractSemver(c.source.VersionStrategy, path, nil)
 	if err != nil {
 		return SemverFile{}, err
 	}
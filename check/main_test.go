@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+func newTestCheck(source model.Source, version model.Version) Check {
+	msg := &chelper.Messager{
+		LogWriter:      ioutil.Discard,
+		ResponseWriter: ioutil.Discard,
+		RequestReader:  strings.NewReader(`{"source": {}}`),
+	}
+	return Check{
+		cmd:     chelper.NewCheckCommandWithMessager(msg),
+		source:  source,
+		version: version,
+	}
+}
+
+func TestRetrieveVersionsOrdered(t *testing.T) {
+	results := []utils.SearchResult{
+		{SearchResult: artutils.SearchResult{Path: "repo/app-1.10.0.tgz", Created: "2021-01-03T00:00:00.000Z", Modified: "2021-01-03T00:00:00.000Z"}, Sha256: "c"},
+		{SearchResult: artutils.SearchResult{Path: "repo/app-1.2.0.tgz", Created: "2021-01-01T00:00:00.000Z", Modified: "2021-01-04T00:00:00.000Z"}, Sha256: "a"},
+		{SearchResult: artutils.SearchResult{Path: "repo/app-latest.tgz", Created: "2021-01-04T00:00:00.000Z", Modified: "2021-01-01T00:00:00.000Z"}, Sha256: "d"},
+		{SearchResult: artutils.SearchResult{Path: "repo/app-1.9.0.tgz", Created: "2021-01-02T00:00:00.000Z", Modified: "2021-01-02T00:00:00.000Z"}, Sha256: "b"},
+	}
+	tests := []struct {
+		name     string
+		orderBy  string
+		current  model.Version
+		results  []utils.SearchResult
+		expected []string
+	}{
+		{
+			name:     "no current version gives only the latest",
+			orderBy:  utils.ORDER_BY_CREATED,
+			results:  results,
+			expected: []string{"repo/app-latest.tgz"},
+		},
+		{
+			name:     "current version and newer ones",
+			orderBy:  utils.ORDER_BY_CREATED,
+			current:  model.Version{Path: "repo/app-1.9.0.tgz"},
+			results:  results,
+			expected: []string{"repo/app-1.9.0.tgz", "repo/app-1.10.0.tgz", "repo/app-latest.tgz"},
+		},
+		{
+			name:     "current version of an older release with only build",
+			orderBy:  utils.ORDER_BY_CREATED,
+			current:  model.Version{BuildNumber: "repo/app-1.10.0.tgz"},
+			results:  results,
+			expected: []string{"repo/app-1.10.0.tgz", "repo/app-latest.tgz"},
+		},
+		{
+			name:     "deleted current version gives only the latest",
+			orderBy:  utils.ORDER_BY_CREATED,
+			current:  model.Version{Path: "repo/app-1.0.0.tgz"},
+			results:  results,
+			expected: []string{"repo/app-latest.tgz"},
+		},
+		{
+			name:     "ordered by modified date",
+			orderBy:  utils.ORDER_BY_MODIFIED,
+			current:  model.Version{Path: "repo/app-1.9.0.tgz"},
+			results:  results,
+			expected: []string{"repo/app-1.9.0.tgz", "repo/app-1.10.0.tgz", "repo/app-1.2.0.tgz"},
+		},
+		{
+			name:     "ordered by path",
+			orderBy:  utils.ORDER_BY_PATH,
+			current:  model.Version{Path: "repo/app-1.9.0.tgz"},
+			results:  results,
+			expected: []string{"repo/app-1.9.0.tgz", "repo/app-latest.tgz"},
+		},
+		{
+			name:     "ordered by semver without files with no version",
+			orderBy:  utils.ORDER_BY_SEMVER,
+			current:  model.Version{Path: "repo/app-1.2.0.tgz"},
+			results:  results,
+			expected: []string{"repo/app-1.2.0.tgz", "repo/app-1.9.0.tgz", "repo/app-1.10.0.tgz"},
+		},
+		{
+			name:     "no file found",
+			orderBy:  utils.ORDER_BY_CREATED,
+			current:  model.Version{Path: "repo/app-1.9.0.tgz"},
+			results:  []utils.SearchResult{},
+			expected: []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			check := newTestCheck(model.Source{OrderBy: test.orderBy}, test.current)
+			versions, err := check.RetrieveVersionsOrdered(test.results)
+			if err != nil {
+				t.Fatalf("RetrieveVersionsOrdered failed: %s", err)
+			}
+			paths := make([]string, 0)
+			for _, version := range versions {
+				paths = append(paths, version.Path)
+			}
+			if !reflect.DeepEqual(paths, test.expected) {
+				t.Errorf("RetrieveVersionsOrdered gives %v, expected %v", paths, test.expected)
+			}
+		})
+	}
+}
+
+func TestResultToVersion(t *testing.T) {
+	check := newTestCheck(model.Source{}, model.Version{})
+	version := check.ResultToVersion(utils.SearchResult{
+		SearchResult: artutils.SearchResult{Path: "repo/app-1.2.3.tgz", Modified: "2021-01-01T00:00:00.000Z"},
+		Sha256:       "abc",
+		Files:        []string{"repo/app-1.2.3.tgz", "repo/app-1.2.3.tgz.asc"},
+	})
+	expected := model.Version{
+		Path:     "repo/app-1.2.3.tgz",
+		Sha256:   "abc",
+		Modified: "2021-01-01T00:00:00.000Z",
+		Semver:   "1.2.3",
+		Files:    "repo/app-1.2.3.tgz,repo/app-1.2.3.tgz.asc",
+	}
+	if version != expected {
+		t.Errorf("ResultToVersion gives %+v, expected %+v", version, expected)
+	}
+}
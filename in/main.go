@@ -1,37 +1,51 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
+	"io/ioutil"
 	"os"
 	"path"
 	fpath "path/filepath"
+	"strings"
 	"time"
 
-	"crypto/tls"
-	"crypto/x509"
-
 	chelper "github.com/ArthurHlt/go-concourse-helper"
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/jfrog/jfrog-cli-core/v2/artifactory/commands/generic"
 	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
 	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
 	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
+	clientutils "github.com/jfrog/jfrog-client-go/utils"
 	"github.com/orange-cloudfoundry/artifactory-resource/model"
 	"github.com/orange-cloudfoundry/artifactory-resource/utils"
 )
 
+const (
+	CHECKSUMS_FILENAME  = "checksums"
+	VERSION_FILENAME    = "version"
+	PATH_FILENAME       = "path"
+	URL_FILENAME        = "url"
+	SHA256_FILENAME     = "sha256"
+	PROPERTIES_FILENAME = "properties.json"
+)
+
 type In struct {
 	cmd        *chelper.InCommand
 	source     model.Source
 	params     model.InParams
+	version    model.Version
 	artdetails *config.ServerDetails
 	spec       *spec.SpecFiles
+	keyring    openpgp.EntityList
 }
 
 func main() {
+	msg, version := utils.NewMessager()
 	in := &In{
-		cmd: chelper.NewInCommand(),
+		cmd:     chelper.NewInCommandWithMessager(msg),
+		version: version,
 	}
 	in.Run()
 }
@@ -45,6 +59,19 @@ func (c *In) Run() {
 	err = cmd.Params(&c.params)
 	msg.FatalIf("Error when parsing params from concourse", err)
 	c.defaultingParams()
+	if c.version.Placeholder != "" {
+		msg.Logln("[cyan]Skipping[reset] get of version '[blue]%s[reset]' which doesn't point to a file (placeholder: %s).", c.version.FilePath(), c.version.Placeholder)
+		msg.SendJsonResponse(model.Response{
+			Metadata: []chelper.Metadata{
+				{
+					Name:  "placeholder",
+					Value: c.version.Placeholder,
+				},
+			},
+			Version: c.version,
+		})
+		return
+	}
 	err = utils.CheckReqParams(c.source)
 	if err != nil {
 		msg.Fatal(err.Error())
@@ -53,17 +80,73 @@ func (c *In) Run() {
 	if err != nil {
 		msg.Fatal(err.Error())
 	}
+	if c.params.VerifySignature {
+		c.keyring, err = ReadPublicKeys(c.source.PublicKeys)
+		if err != nil {
+			msg.Fatal(err.Error())
+		}
+	}
+
+	filePath := c.version.FilePath()
+	info, err := utils.RetrieveStorageInfo(c.artdetails, c.source.CACert, filePath)
+	msg.FatalIf("Error when retrieving file info", err)
+
+	var groupInfos map[string]utils.StorageInfo
+	if c.version.Files != "" {
+		groupInfos, err = c.RetrieveGroupInfos()
+		msg.FatalIf("Error when verifying group", err)
+	}
+
+	metadata := make([]chelper.Metadata, 0)
+	if c.params.SkipDownload {
+		msg.Logln("[cyan]Skipping[reset] download of file '[blue]%s[reset]'.", filePath)
+		if c.version.Files == "" && c.version.Sha256 != "" && c.version.Sha256 != info.Checksums.Sha256 {
+			msg.Fatal(fmt.Sprintf("sha256 of file '%s' is '%s' but version expects '%s'", filePath, info.Checksums.Sha256, c.version.Sha256))
+		}
+		metadata = append(metadata, chelper.Metadata{
+			Name:  "skipped_download",
+			Value: filePath,
+		})
+	} else if c.version.Files != "" {
+		metadata = append(metadata, c.DownloadGroup(groupInfos)...)
+	} else {
+		c.spec = c.buildSpec(filePath, c.params.Filename)
+		metadata = append(metadata, c.DownloadAndVerify(filePath, info)...)
+	}
+
+	err = c.WriteChecksums(info.Checksums)
+	msg.FatalIf("Error when writing checksums file", err)
 
-	filePath := c.cmd.Version().BuildNumber
+	if c.params.PropsFilename != "" {
+		msg.Logln("\n[blue]Downloading properties[reset] file '[blue]%s[reset]'.", c.params.PropsFilename)
+		err = c.DownloadProperties()
+		msg.FatalIf("Error downloading properties", err)
+		msg.Logln("\n[blue]Finished downloading properties[reset] file '[blue]%s[reset]'.", c.params.PropsFilename)
+	}
+
+	props, err := utils.RetrieveStorageProperties(c.artdetails, c.source.CACert, filePath)
+	msg.FatalIf("Error when retrieving properties", err)
+	err = c.WriteVersionFiles(info, props)
+	msg.FatalIf("Error when writing version files", err)
+
+	metadata = append(metadata, c.InfoMetadata(info)...)
+	metadata = append(metadata, c.PropsMetadata(props)...)
+	msg.SendJsonResponse(model.Response{
+		Metadata: metadata,
+		Version:  c.version,
+	})
+}
+
+func (c In) buildSpec(filePath string, filename string) *spec.SpecFiles {
 	dest := utils.AddTrailingSlashIfNeeded(c.cmd.DestinationFolder())
-	if c.params.Filename != "" {
-		dest += c.params.Filename
+	if filename != "" {
+		dest += filename
 	} else {
 		dest += fpath.Base(filePath)
 	}
 
 	builder := spec.NewBuilder()
-	c.spec = builder.
+	return builder.
 		Pattern(filePath).
 		Target(dest).
 		Props(c.source.Props).
@@ -71,23 +154,81 @@ func (c *In) Run() {
 		Recursive(false).
 		Flat(!c.params.Notflat).
 		BuildSpec()
+}
 
+// RetrieveGroupInfos gives storage info of every file of the group in version,
+// sha256 of the group computed from them must be the one pinned in version (if set) as check computes it.
+func (c In) RetrieveGroupInfos() (map[string]utils.StorageInfo, error) {
+	infos := make(map[string]utils.StorageInfo)
+	files := make([]utils.SearchResult, 0)
+	for _, groupFile := range c.version.GroupFiles() {
+		info, err := utils.RetrieveStorageInfo(c.artdetails, c.source.CACert, groupFile)
+		if err != nil {
+			return nil, err
+		}
+		infos[groupFile] = info
+		file := utils.SearchResult{Sha256: info.Checksums.Sha256}
+		file.Path = groupFile
+		file.Sha1 = info.Checksums.Sha1
+		files = append(files, file)
+	}
+	groupSha256 := utils.GroupSha256(files)
+	if c.version.Sha256 != "" && c.version.Sha256 != groupSha256 {
+		return nil, fmt.Errorf("sha256 of group '%s' is '%s' but version expects '%s', a file of the group has changed", c.version.FilePath(), groupSha256, c.version.Sha256)
+	}
+	return infos, nil
+}
+
+// DownloadGroup downloads every file of the group in version which are not filtered by include_suffixes and exclude_suffixes.
+func (c *In) DownloadGroup(infos map[string]utils.StorageInfo) []chelper.Metadata {
+	msg := c.cmd.Messager()
+	metadata := make([]chelper.Metadata, 0)
+	for _, groupFile := range c.version.GroupFiles() {
+		if !c.isSuffixAllowed(groupFile) {
+			msg.Logln("[cyan]Skipping[reset] file '[blue]%s[reset]' of group.", groupFile)
+			continue
+		}
+		c.spec = c.buildSpec(groupFile, "")
+		metadata = append(metadata, c.DownloadAndVerify(groupFile, infos[groupFile])...)
+	}
+	return metadata
+}
+
+func (c In) isSuffixAllowed(filePath string) bool {
+	for _, suffix := range c.params.ExcludeSuffixes {
+		if strings.HasSuffix(filePath, suffix) {
+			return false
+		}
+	}
+	if len(c.params.IncludeSuffixes) == 0 {
+		return true
+	}
+	for _, suffix := range c.params.IncludeSuffixes {
+		if strings.HasSuffix(filePath, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// DownloadAndVerify downloads file and checks its checksums, it gives download metadata.
+func (c In) DownloadAndVerify(filePath string, info utils.StorageInfo) []chelper.Metadata {
+	msg := c.cmd.Messager()
 	msg.Log("[blue]Downloading[reset] file '[blue]%s[reset]'...", filePath)
 	startDl := time.Now()
 	origStdout := os.Stdout
 	os.Stdout = os.Stderr
-	err = c.Download()
+	localPaths, err := c.Download()
 	os.Stdout = origStdout
 	msg.FatalIf("Error when downloading", err)
+	if len(localPaths) == 0 {
+		msg.Fatal(fmt.Sprintf("File '%s' not found in artifactory", filePath))
+	}
 	elapsed := time.Since(startDl)
 	msg.Log("[blue]Finished downloading[reset] file '[blue]%s[reset]'.", filePath)
 
-	if c.params.PropsFilename != "" {
-		msg.Logln("\n[blue]Downloading properties[reset] file '[blue]%s[reset]'.", c.params.PropsFilename)
-		err = c.DownloadProperties()
-		msg.FatalIf("Error downloading properties", err)
-		msg.Logln("\n[blue]Finished downloading properties[reset] file '[blue]%s[reset]'.", c.params.PropsFilename)
-	}
+	err = c.VerifyChecksums(localPaths[0], info.Checksums)
+	msg.FatalIf("Error when verifying downloaded file", err)
 
 	metadata := []chelper.Metadata{
 		{
@@ -99,7 +240,138 @@ func (c *In) Run() {
 			Value: elapsed.String(),
 		},
 	}
-	cmd.Send(metadata)
+	switch {
+	// signatures downloaded in a group are not signed themselves
+	case !c.params.VerifySignature || isSignatureFile(filePath):
+	case !c.isSigned(filePath):
+		msg.Logln("[cyan]Skipping[reset] signature verification of file '[blue]%s[reset]' which has no signature in group.", filePath)
+	default:
+		fingerprint, err := c.VerifySignature(filePath, localPaths[0])
+		if err != nil {
+			os.Remove(localPaths[0])
+		}
+		msg.FatalIf("Error when verifying signature", err)
+		msg.Logln("[green]Valid signature[reset] of file '[blue]%s[reset]' by key '[blue]%s[reset]'.", filePath, fingerprint)
+		metadata = append(metadata, chelper.Metadata{
+			Name:  "signature_fingerprint",
+			Value: fingerprint,
+		})
+	}
+
+	if c.params.Unpack {
+		c.UnpackFile(localPaths[0])
+	}
+
+	return metadata
+}
+
+// UnpackFile unpacks a downloaded archive, files in a group which are not archives are skipped.
+func (c In) UnpackFile(localPath string) {
+	msg := c.cmd.Messager()
+	walker, ok := RetrieveArchiveWalker(localPath)
+	if !ok && c.version.Files != "" {
+		msg.Logln("[cyan]Skipping[reset] unpack of file '[blue]%s[reset]' which is not an archive.", fpath.Base(localPath))
+		return
+	}
+	if !ok {
+		msg.Fatal(fmt.Sprintf("File '%s' is not a supported archive (e.g.: tar.gz, tgz, zip, tar.xz)", fpath.Base(localPath)))
+	}
+	msg.Logln("[blue]Unpacking[reset] file '[blue]%s[reset]'...", fpath.Base(localPath))
+	err := c.Unpack(localPath, walker)
+	msg.FatalIf("Error when unpacking", err)
+}
+
+func (c In) InfoMetadata(info utils.StorageInfo) []chelper.Metadata {
+	return []chelper.Metadata{
+		{
+			Name:  "size",
+			Value: info.Size,
+		},
+		{
+			Name:  "sha256",
+			Value: info.Checksums.Sha256,
+		},
+		{
+			Name:  "sha1",
+			Value: info.Checksums.Sha1,
+		},
+		{
+			Name:  "md5",
+			Value: info.Checksums.Md5,
+		},
+		{
+			Name:  "created",
+			Value: fmt.Sprintf("%s by %s", info.Created, info.CreatedBy),
+		},
+		{
+			Name:  "modified",
+			Value: fmt.Sprintf("%s by %s", info.LastModified, info.ModifiedBy),
+		},
+		{
+			Name:  "download_url",
+			Value: info.DownloadUri,
+		},
+	}
+}
+
+// PropsMetadata gives metadata for properties of the file listed in metadata_props param.
+func (c In) PropsMetadata(props map[string][]string) []chelper.Metadata {
+	metadata := make([]chelper.Metadata, 0)
+	for _, key := range c.params.MetadataProps {
+		values, ok := props[key]
+		if !ok {
+			continue
+		}
+		metadata = append(metadata, chelper.Metadata{
+			Name:  "prop." + key,
+			Value: strings.Join(values, ","),
+		})
+	}
+	return metadata
+}
+
+// WriteVersionFiles writes files describing downloaded file in destination folder for next tasks:
+// version (semver found), path, url, sha256 and properties.json.
+func (c In) WriteVersionFiles(info utils.StorageInfo, props map[string][]string) error {
+	msg := c.cmd.Messager()
+	files := map[string]string{
+		PATH_FILENAME:   c.version.FilePath(),
+		URL_FILENAME:    info.DownloadUri,
+		SHA256_FILENAME: info.Checksums.Sha256,
+	}
+	semverFound := c.version.Semver
+	if semverFound == "" {
+		fileSemver, err := utils.ExtractSemver(c.source.VersionStrategy, c.version.FilePath(), props)
+		if err == nil {
+			semverFound = fileSemver.String()
+		} else {
+			msg.Logln("[yellow]No version[reset] file written: %s [reset]", err.Error())
+		}
+	}
+	if semverFound != "" {
+		files[VERSION_FILENAME] = semverFound
+	}
+	for filename, content := range files {
+		err := c.writeGetFile(filename, []byte(content))
+		if err != nil {
+			return err
+		}
+	}
+	propsContent, err := json.Marshal(props)
+	if err != nil {
+		return err
+	}
+	return c.writeGetFile(PROPERTIES_FILENAME, propsContent)
+}
+
+// writeGetFile writes a file about the version in destination folder,
+// it fails instead of overwriting a downloaded (or unpacked) file with the same name.
+func (c In) writeGetFile(filename string, content []byte) error {
+	filePath := utils.AddTrailingSlashIfNeeded(c.cmd.DestinationFolder()) + filename
+	if _, err := os.Lstat(filePath); err == nil {
+		return fmt.Errorf("Downloaded file '%s' has the same name as a file written by get, use filename or unpack_dir to put it elsewhere.", filename)
+	}
+	return ioutil.WriteFile(filePath, content, 0644)
 }
 
 func (c *In) defaultingParams() {
@@ -114,7 +386,8 @@ func (c *In) defaultingParams() {
 	}
 }
 
-func (c In) Download() error {
+// Download gives back local paths of downloaded files.
+func (c In) Download() ([]string, error) {
 	cmd := generic.NewDownloadCommand()
 	cmd.SetConfiguration(&artutils.DownloadConfiguration{
 		Threads:      c.params.Threads,
@@ -124,34 +397,67 @@ func (c In) Download() error {
 
 	cmd.
 		SetServerDetails(c.artdetails).
-		SetSpec(c.spec)
+		SetSpec(c.spec).
+		SetDetailedSummary(true)
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, err
+	}
+	localPaths := make([]string, 0)
+	reader := cmd.Result().Reader()
+	if reader == nil {
+		return localPaths, nil
+	}
+	defer reader.Close()
+	for val := new(clientutils.FileTransferDetails); reader.NextRecord(val) == nil; val = new(clientutils.FileTransferDetails) {
+		localPaths = append(localPaths, val.TargetPath)
+	}
+	return localPaths, reader.GetError()
+}
+
+// VerifyChecksums checks that downloaded file match checksums stored in artifactory and the sha256 pinned in version.
+func (c In) VerifyChecksums(localPath string, checksums utils.StorageChecksums) error {
+	localChecksums, err := utils.FileChecksums(localPath)
+	if err != nil {
+		return err
+	}
+	checks := [][3]string{
+		{"sha256", checksums.Sha256, localChecksums.Sha256},
+		{"sha1", checksums.Sha1, localChecksums.Sha1},
+		{"md5", checksums.Md5, localChecksums.Md5},
+	}
+	// sha256 of a group version is computed from all files of the group, it is verified before downloading
+	if c.version.Files == "" {
+		checks = append(checks, [3]string{"sha256 pinned in version", c.version.Sha256, localChecksums.Sha256})
+	}
+	diff := ""
+	for _, check := range checks {
+		name, expected, actual := check[0], check[1], check[2]
+		if expected == "" || expected == actual {
+			continue
+		}
+		diff += fmt.Sprintf("\n  %s: expected '%s' but got '%s'", name, expected, actual)
+	}
+	if diff != "" {
+		return fmt.Errorf("checksums of file '%s' don't match:%s", localPath, diff)
+	}
+	return nil
+}
 
-	return cmd.Run()
+// WriteChecksums writes checksums of downloaded file in a file named checksums in destination folder.
+func (c In) WriteChecksums(checksums utils.StorageChecksums) error {
+	content := fmt.Sprintf("sha256: %s\nsha1: %s\nmd5: %s\n", checksums.Sha256, checksums.Sha1, checksums.Md5)
+	return c.writeGetFile(CHECKSUMS_FILENAME, []byte(content))
 }
 
 func (c In) DownloadProperties() error {
 	msg := c.cmd.Messager()
 
-	url := fmt.Sprintf("%sapi/storage/%s?properties", c.artdetails.Url, c.cmd.Version().BuildNumber)
-
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := utils.NewStorageRequest(c.artdetails, c.version.FilePath(), "properties")
+	msg.FatalIf("Error downloading properties", err)
+	client, err := utils.NewHttpClient(c.source.CACert)
 	msg.FatalIf("Error downloading properties", err)
-	req.SetBasicAuth(c.artdetails.GetUser(), c.artdetails.GetPassword())
-	client := &http.Client{}
-	if c.source.CACert != "" {
-		caPool := x509.NewCertPool()
-		ok := caPool.AppendCertsFromPEM([]byte(c.source.CACert))
-		if !ok {
-			msg.Logln(fmt.Sprintf("%v", err))
-			msg.Fatal("Error parsing pem certificate")
-		}
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs: caPool,
-			},
-		}
-		client.Transport = tr
-	}
 	resp, err := client.Do(req)
 	msg.FatalIf("Error downloading properties", err)
 	defer resp.Body.Close()
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+func TestWriteVersionFiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		downloaded string
+		wantErr    bool
+	}{
+		{name: "no collision", downloaded: "app-1.2.3.tgz"},
+		{name: "downloaded version file", downloaded: VERSION_FILENAME, wantErr: true},
+		{name: "downloaded properties file", downloaded: PROPERTIES_FILENAME, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			destination := t.TempDir()
+			downloadedPath := filepath.Join(destination, test.downloaded)
+			err := ioutil.WriteFile(downloadedPath, []byte("downloaded"), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in := newUnpackIn(t, destination, model.InParams{})
+			in.version = model.Version{Path: "repo/app/app-1.2.3.tgz"}
+			err = in.WriteVersionFiles(utils.StorageInfo{}, map[string][]string{})
+			content, readErr := ioutil.ReadFile(downloadedPath)
+			if readErr != nil || string(content) != "downloaded" {
+				t.Fatalf("downloaded file '%s' has been overwritten", test.downloaded)
+			}
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("WriteVersionFiles should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WriteVersionFiles failed: %s", err)
+			}
+			for _, filename := range []string{VERSION_FILENAME, PATH_FILENAME, URL_FILENAME, SHA256_FILENAME, PROPERTIES_FILENAME} {
+				if _, err := os.Stat(filepath.Join(destination, filename)); err != nil {
+					t.Errorf("%s has not been written: %s", filename, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRetrieveGroupInfos(t *testing.T) {
+	storage := map[string]utils.StorageChecksums{
+		"repo/app/app-1.2.3.tgz":        {Sha256: "tgz256", Sha1: "tgz1"},
+		"repo/app/app-1.2.3.tgz.sha256": {Sha1: "sha1"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checksums, ok := storage[strings.TrimPrefix(r.URL.Path, "/api/storage/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(utils.StorageInfo{Checksums: checksums})
+	}))
+	defer server.Close()
+
+	// sha256 of the group as check computes it from search results
+	results := make([]utils.SearchResult, 0)
+	for path, checksums := range storage {
+		result := utils.SearchResult{Sha256: checksums.Sha256}
+		result.Path = path
+		result.Sha1 = checksums.Sha1
+		results = append(results, result)
+	}
+	group := utils.MergeGroup(results)
+
+	tests := []struct {
+		name    string
+		version model.Version
+		changed string
+		wantErr bool
+	}{
+		{
+			name:    "unchanged group",
+			version: utils.ResultToVersion(group, model.VersionStrategy{}),
+		},
+		{
+			name:    "file changed after check",
+			version: utils.ResultToVersion(group, model.VersionStrategy{}),
+			changed: "repo/app/app-1.2.3.tgz.sha256",
+			wantErr: true,
+		},
+		{
+			name:    "no sha256 pinned",
+			version: model.Version{Path: group.Path, Files: strings.Join(group.Files, ",")},
+			changed: "repo/app/app-1.2.3.tgz",
+		},
+		{
+			name:    "file deleted after check",
+			version: model.Version{Path: group.Path, Files: strings.Join(append(group.Files, "repo/app/app-1.2.3.sbom.json"), ",")},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.changed != "" {
+				orig := storage[test.changed]
+				storage[test.changed] = utils.StorageChecksums{Sha256: "changed", Sha1: "changed"}
+				defer func() {
+					storage[test.changed] = orig
+				}()
+			}
+			in := newUnpackIn(t, t.TempDir(), model.InParams{})
+			in.version = test.version
+			in.artdetails = &config.ServerDetails{Url: server.URL + "/"}
+			infos, err := in.RetrieveGroupInfos()
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("RetrieveGroupInfos should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RetrieveGroupInfos failed: %s", err)
+			}
+			if len(infos) != len(group.Files) {
+				t.Errorf("RetrieveGroupInfos gives %d infos, expected %d", len(infos), len(group.Files))
+			}
+		})
+	}
+}
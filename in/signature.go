@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+const (
+	PUBLIC_KEY_HEADER = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	SIGNATURE_HEADER  = "-----BEGIN PGP SIGNATURE-----"
+)
+
+// SIGNATURE_EXTS are extensions of detached signatures looked up next to a file, in order.
+var SIGNATURE_EXTS = []string{".asc", ".sig"}
+
+// ReadPublicKeys gives keyring from one or more armored public keys put one after the other.
+func ReadPublicKeys(armoredKeys string) (openpgp.EntityList, error) {
+	keyring := openpgp.EntityList{}
+	blocks := strings.Split(armoredKeys, PUBLIC_KEY_HEADER)
+	for _, block := range blocks[1:] {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(PUBLIC_KEY_HEADER + block))
+		if err != nil {
+			return nil, fmt.Errorf("Error when reading public keys: %s", err.Error())
+		}
+		keyring = append(keyring, entities...)
+	}
+	if len(keyring) == 0 {
+		return nil, errors.New("You must provide at least one armored public key in public_keys to verify signatures.")
+	}
+	return keyring, nil
+}
+
+func isSignatureFile(filePath string) bool {
+	for _, ext := range SIGNATURE_EXTS {
+		if strings.HasSuffix(filePath, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSigned tells if a file must have a signature: a single file or the primary file of a group always must,
+// other files of a group only when their signature is part of the group.
+func (c In) isSigned(filePath string) bool {
+	if c.version.Files == "" || filePath == c.version.FilePath() {
+		return true
+	}
+	for _, groupFile := range c.version.GroupFiles() {
+		for _, ext := range SIGNATURE_EXTS {
+			if groupFile == filePath+ext {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// VerifySignature checks downloaded file against its detached signature (.asc or .sig) stored in artifactory,
+// it gives the fingerprint of the key which signed the file.
+func (c In) VerifySignature(filePath string, localPath string) (string, error) {
+	for _, ext := range SIGNATURE_EXTS {
+		signature, found, err := utils.RetrieveFileContent(c.artdetails, c.source.CACert, filePath+ext)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			continue
+		}
+		file, err := os.Open(localPath)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		checkSignature := openpgp.CheckDetachedSignature
+		if bytes.HasPrefix(bytes.TrimSpace(signature), []byte(SIGNATURE_HEADER)) {
+			checkSignature = openpgp.CheckArmoredDetachedSignature
+		}
+		signer, err := checkSignature(c.keyring, file, bytes.NewReader(signature), nil)
+		if err != nil {
+			return "", fmt.Errorf("Signature '%s' of file '%s' is invalid: %s", filePath+ext, filePath, err.Error())
+		}
+		return fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint), nil
+	}
+	return "", fmt.Errorf("No signature found for file '%s' (looked for '%s')", filePath, strings.Join(SIGNATURE_EXTS, "', '"))
+}
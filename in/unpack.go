@@ -0,0 +1,192 @@
+package main
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zip"
+	"github.com/mholt/archiver/v3"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// RetrieveArchiveWalker gives a walker for archives (e.g.: tar.gz, tgz, zip, tar.xz), ok is false when file is not an archive.
+func RetrieveArchiveWalker(archivePath string) (archiver.Walker, bool) {
+	unarchiver, err := archiver.ByExtension(filepath.Base(archivePath))
+	if err != nil {
+		return nil, false
+	}
+	walker, ok := unarchiver.(archiver.Walker)
+	return walker, ok
+}
+
+// Unpack extracts archive in unpack_dir (or destination folder) after removing strip_components leading folders of each file,
+// files which would be written outside of unpack directory are refused.
+func (c In) Unpack(archivePath string, walker archiver.Walker) error {
+	unpackDir, err := filepath.Abs(filepath.Join(c.cmd.DestinationFolder(), c.params.UnpackDir))
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(unpackDir, 0755)
+	if err != nil {
+		return err
+	}
+	// links extracted before are followed when checking paths, so they can't be used to escape unpack directory
+	unpackDir, err = filepath.EvalSymlinks(unpackDir)
+	if err != nil {
+		return err
+	}
+	err = walker.Walk(archivePath, func(f archiver.File) error {
+		name, linkname := archivedNames(f)
+		name = stripComponents(name, c.params.StripComponents)
+		if name == "" {
+			return nil
+		}
+		target, err := resolveWithin(unpackDir, unpackDir+"/"+name)
+		if err != nil {
+			return fmt.Errorf("illegal file path in archive: '%s': %s", name, err.Error())
+		}
+		switch {
+		case f.IsDir():
+			return os.MkdirAll(target, 0755)
+		case f.Mode()&os.ModeSymlink != 0:
+			if linkname == "" {
+				// zip keeps link target as content of the entry
+				content, err := ioutil.ReadAll(f)
+				if err != nil {
+					return err
+				}
+				linkname = string(content)
+			}
+			if linkname == "" {
+				return fmt.Errorf("symbolic link '%s' in archive has no target", name)
+			}
+			linkTarget := linkname
+			if !filepath.IsAbs(linkTarget) {
+				linkTarget = filepath.Dir(target) + "/" + linkTarget
+			}
+			_, err := resolveWithin(unpackDir, linkTarget)
+			if err != nil {
+				return fmt.Errorf("illegal symbolic link in archive: '%s' -> '%s': %s", name, linkname, err.Error())
+			}
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(linkname, target)
+		case linkname != "":
+			// hard link, target is relative to archive root
+			linkTarget, err := resolveWithin(unpackDir, unpackDir+"/"+stripComponents(linkname, c.params.StripComponents))
+			if err != nil {
+				return fmt.Errorf("illegal hard link in archive: '%s' -> '%s': %s", name, linkname, err.Error())
+			}
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+			if err != nil {
+				return err
+			}
+			return os.Link(linkTarget, target)
+		}
+		return writeArchivedFile(target, f)
+	})
+	if err != nil {
+		return err
+	}
+	if c.params.DeleteArchive {
+		return os.Remove(archivePath)
+	}
+	return nil
+}
+
+func archivedNames(f archiver.File) (string, string) {
+	switch header := f.Header.(type) {
+	case *tar.Header:
+		linkname := ""
+		if header.Typeflag == tar.TypeSymlink || header.Typeflag == tar.TypeLink {
+			linkname = header.Linkname
+		}
+		return header.Name, linkname
+	case zip.FileHeader:
+		return header.Name, ""
+	}
+	return f.Name(), ""
+}
+
+func stripComponents(name string, nb int) string {
+	name = utils.RemoveStartingSlashIfNeeded(filepath.ToSlash(name))
+	name = strings.TrimPrefix(name, "./")
+	if nb <= 0 {
+		return name
+	}
+	components := strings.Split(strings.TrimSuffix(name, "/"), "/")
+	if len(components) <= nb {
+		return ""
+	}
+	return strings.Join(components[nb:], "/")
+}
+
+func isWithin(parent, sub string) bool {
+	rel, err := filepath.Rel(parent, sub)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveWithin resolves path component by component following symbolic links already on disk,
+// path must not be cleaned before as '..' after a link goes to the parent of link target.
+// It fails when the resolved path is outside of parent or when a link is broken.
+func resolveWithin(parent, path string) (string, error) {
+	resolved := string(filepath.Separator)
+	components := strings.Split(filepath.ToSlash(path), "/")
+	for i, component := range components {
+		if component == "" || component == "." {
+			continue
+		}
+		if component == ".." {
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+		next := filepath.Join(resolved, component)
+		info, err := os.Lstat(next)
+		if os.IsNotExist(err) {
+			// nothing exists below, there is no more link to follow
+			resolved = filepath.Join(append([]string{next}, components[i+1:]...)...)
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			next, err = filepath.EvalSymlinks(next)
+			if os.IsNotExist(err) {
+				return "", fmt.Errorf("broken symbolic link '%s'", filepath.Join(resolved, component))
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+		resolved = next
+	}
+	if !isWithin(parent, resolved) {
+		return "", fmt.Errorf("'%s' is outside of '%s'", resolved, parent)
+	}
+	return resolved, nil
+}
+
+func writeArchivedFile(target string, f archiver.File) error {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, f.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, f)
+	return err
+}
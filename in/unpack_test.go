@@ -0,0 +1,306 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	content  string
+}
+
+func writeTar(t *testing.T, path string, entries []tarEntry) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Linkname: entry.linkname,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+		}
+		if entry.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeZip writes entries described as tar entries in a zip, hard links are not supported by zip.
+func writeZip(t *testing.T, path string, entries []tarEntry) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, entry := range entries {
+		header := &zip.FileHeader{
+			Name:   entry.name,
+			Method: zip.Deflate,
+		}
+		content := entry.content
+		switch entry.typeflag {
+		case tar.TypeDir:
+			header.SetMode(os.ModeDir | 0755)
+		case tar.TypeSymlink:
+			header.SetMode(os.ModeSymlink | 0777)
+			content = entry.linkname
+		default:
+			header.SetMode(0644)
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newUnpackIn(t *testing.T, destination string, params model.InParams) In {
+	msg := &chelper.Messager{
+		LogWriter:      ioutil.Discard,
+		ResponseWriter: ioutil.Discard,
+		RequestReader:  strings.NewReader(`{"source": {}, "version": {}}`),
+		Directory:      destination,
+	}
+	return In{
+		cmd:    chelper.NewInCommandWithMessager(msg),
+		params: params,
+	}
+}
+
+func TestStripComponents(t *testing.T) {
+	tests := []struct {
+		name     string
+		nb       int
+		expected string
+	}{
+		{name: "a/b/c.txt", nb: 0, expected: "a/b/c.txt"},
+		{name: "./a/b/c.txt", nb: 0, expected: "a/b/c.txt"},
+		{name: "/a/b/c.txt", nb: 0, expected: "a/b/c.txt"},
+		{name: "a/b/c.txt", nb: 1, expected: "b/c.txt"},
+		{name: "./a/b/c.txt", nb: 2, expected: "c.txt"},
+		{name: "a/b/", nb: 1, expected: "b"},
+		{name: "a/b/c.txt", nb: 3, expected: ""},
+		{name: "a/", nb: 1, expected: ""},
+	}
+	for _, test := range tests {
+		if stripped := stripComponents(test.name, test.nb); stripped != test.expected {
+			t.Errorf("stripComponents(%q, %d) = %q, expected %q", test.name, test.nb, stripped, test.expected)
+		}
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	tests := []struct {
+		sub      string
+		expected bool
+	}{
+		{sub: "/unpack", expected: true},
+		{sub: "/unpack/a/b", expected: true},
+		{sub: "/unpack/..a", expected: true},
+		{sub: "/unpack/a/../b", expected: true},
+		{sub: "/unpack/..", expected: false},
+		{sub: "/unpack/../etc/passwd", expected: false},
+		{sub: "/unpacked", expected: false},
+		{sub: "/etc", expected: false},
+	}
+	for _, test := range tests {
+		if within := isWithin("/unpack", test.sub); within != test.expected {
+			t.Errorf("isWithin(%q, %q) = %t, expected %t", "/unpack", test.sub, within, test.expected)
+		}
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	tests := []struct {
+		name            string
+		zip             bool
+		entries         []tarEntry
+		stripComponents int
+		wantErr         bool
+		expectedFiles   map[string]string
+	}{
+		{
+			name: "regular archive",
+			entries: []tarEntry{
+				{name: "app/", typeflag: tar.TypeDir},
+				{name: "app/bin/run.sh", typeflag: tar.TypeReg, content: "run"},
+				{name: "app/current", typeflag: tar.TypeSymlink, linkname: "bin"},
+				{name: "app/run.sh", typeflag: tar.TypeLink, linkname: "app/bin/run.sh"},
+			},
+			stripComponents: 1,
+			expectedFiles: map[string]string{
+				"bin/run.sh":     "run",
+				"current/run.sh": "run",
+				"run.sh":         "run",
+			},
+		},
+		{
+			name: "symbolic link to a file not yet extracted",
+			entries: []tarEntry{
+				{name: "lib.so", typeflag: tar.TypeSymlink, linkname: "lib.so.1"},
+				{name: "lib.so.1", typeflag: tar.TypeReg, content: "lib"},
+			},
+			expectedFiles: map[string]string{
+				"lib.so": "lib",
+			},
+		},
+		{
+			name: "zip with symbolic link",
+			zip:  true,
+			entries: []tarEntry{
+				{name: "app/", typeflag: tar.TypeDir},
+				{name: "app/bin/run.sh", typeflag: tar.TypeReg, content: "run"},
+				{name: "app/current", typeflag: tar.TypeSymlink, linkname: "bin"},
+			},
+			expectedFiles: map[string]string{
+				"app/bin/run.sh":     "run",
+				"app/current/run.sh": "run",
+			},
+		},
+		{
+			name: "zip with symbolic link outside",
+			zip:  true,
+			entries: []tarEntry{
+				{name: "l", typeflag: tar.TypeSymlink, linkname: "../"},
+				{name: "l/pwned.txt", typeflag: tar.TypeReg, content: "pwned"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "zip with chained symbolic links",
+			zip:  true,
+			entries: []tarEntry{
+				{name: "d/", typeflag: tar.TypeDir},
+				{name: "d/l", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "x", typeflag: tar.TypeSymlink, linkname: "d/l/.."},
+				{name: "x/pwned.txt", typeflag: tar.TypeReg, content: "pwned"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "parent path",
+			entries: []tarEntry{
+				{name: "../pwned.txt", typeflag: tar.TypeReg, content: "pwned"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "symbolic link outside",
+			entries: []tarEntry{
+				{name: "l", typeflag: tar.TypeSymlink, linkname: "../"},
+				{name: "l/pwned.txt", typeflag: tar.TypeReg, content: "pwned"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "absolute symbolic link",
+			entries: []tarEntry{
+				{name: "l", typeflag: tar.TypeSymlink, linkname: "/tmp"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "chained symbolic links",
+			entries: []tarEntry{
+				{name: "d/", typeflag: tar.TypeDir},
+				{name: "d/l", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "x", typeflag: tar.TypeSymlink, linkname: "d/l/.."},
+				{name: "x/pwned.txt", typeflag: tar.TypeReg, content: "pwned"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "file through a broken symbolic link",
+			entries: []tarEntry{
+				{name: "l", typeflag: tar.TypeSymlink, linkname: "missing"},
+				{name: "l/pwned.txt", typeflag: tar.TypeReg, content: "pwned"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "hard link outside",
+			entries: []tarEntry{
+				{name: "passwd", typeflag: tar.TypeLink, linkname: "../../etc/passwd"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpDir, err := ioutil.TempDir("", "unpack")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpDir)
+			archivePath := filepath.Join(tmpDir, "archive.tar")
+			if test.zip {
+				archivePath = filepath.Join(tmpDir, "archive.zip")
+				writeZip(t, archivePath, test.entries)
+			} else {
+				writeTar(t, archivePath, test.entries)
+			}
+			destination := filepath.Join(tmpDir, "destination")
+
+			walker, ok := RetrieveArchiveWalker(archivePath)
+			if !ok {
+				t.Fatal("file must be an archive")
+			}
+			in := newUnpackIn(t, destination, model.InParams{StripComponents: test.stripComponents})
+			err = in.Unpack(archivePath, walker)
+			if _, statErr := os.Lstat(filepath.Join(tmpDir, "pwned.txt")); statErr == nil {
+				t.Fatal("file has been written outside of destination")
+			}
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("Unpack should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unpack failed: %s", err)
+			}
+			for name, expected := range test.expectedFiles {
+				content, err := ioutil.ReadFile(filepath.Join(destination, name))
+				if err != nil {
+					t.Errorf("%s has not been extracted: %s", name, err)
+					continue
+				}
+				if string(content) != expected {
+					t.Errorf("%s contains %q, expected %q", name, content, expected)
+				}
+			}
+		})
+	}
+}
@@ -1,28 +1,73 @@
 package model
 
+import (
+	"strings"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+)
+
 type Source struct {
-	Url       string `json:"url"`
-	User      string `json:"user"`
-	Password  string `json:"password"`
-	ApiKey    string `json:"apiKey"`
-	SshKey    string `json:"ssh_key"`
-	Pattern   string `json:"pattern"`
-	Props     string `json:"props"`
-	Recursive bool   `json:"recursive"`
-	Flat      bool   `json:"flat"`
-	Regexp    bool   `json:"regexp"`
-	Version   string `json:"version"`
-	LogLevel  string `json:"log_level"`
-	CACert    string `json:"ca_cert"`
+	Url                string          `json:"url"`
+	User               string          `json:"user"`
+	Password           string          `json:"password"`
+	ApiKey             string          `json:"api_key"`
+	LegacyApiKey       string          `json:"apiKey"`
+	AccessToken        string          `json:"access_token"`
+	SshKey             string          `json:"ssh_key"`
+	Pattern            string          `json:"pattern"`
+	Props              string          `json:"props"`
+	Recursive          bool            `json:"recursive"`
+	Flat               bool            `json:"flat"`
+	Regexp             bool            `json:"regexp"`
+	Version            string          `json:"version"`
+	IncludePrereleases bool            `json:"include_prereleases"`
+	OrderBy            string          `json:"order_by"`
+	LogLevel           string          `json:"log_level"`
+	CACert             string          `json:"ca_cert"`
+	VersionStrategy    VersionStrategy `json:"version_strategy"`
+	Query              *AqlQuery       `json:"query"`
+	GroupBy            string          `json:"group_by"`
+	PublicKeys         string          `json:"public_keys"`
+}
+
+// AqlQuery is an artifactory aql query on items, it replaces pattern, props and recursive when set.
+type AqlQuery struct {
+	// Find is the criteria given to items.find (e.g.: {"repo": "libs", "name": {"$match": "*.tgz"}})
+	Find map[string]interface{} `json:"items.find"`
+	// Sort is given as is to sort (e.g.: {"$desc": ["created"]})
+	Sort map[string][]string `json:"sort"`
+	// Limit is the max number of items found, 0 means no limit
+	Limit int `json:"limit"`
+}
+
+// VersionStrategy defines where semver of a file must be found, only one of them can be set.
+// When none is set semver is found in file name.
+type VersionStrategy struct {
+	// Regex with a named capture group `version` applied on the full artifactory path
+	Regex string `json:"regex"`
+	// PathSegment is an index of the artifactory path splitted on `/`, negative index starts from the end (-1 is file name)
+	PathSegment *int `json:"path_segment"`
+	// Property is the key of an artifactory property holding the version
+	Property string `json:"property"`
 }
 
 type InParams struct {
-	Filename      string `json:"filename"`
-	Notflat       bool   `json:"not_flat"`
-	Threads       int    `json:"threads"`
-	MinSplit      int    `json:"min_split"`
-	SplitCount    int    `json:"split_count"`
-	PropsFilename string `json:"props_filename"`
+	Filename      string   `json:"filename"`
+	Notflat       bool     `json:"not_flat"`
+	Threads       int      `json:"threads"`
+	MinSplit      int      `json:"min_split"`
+	SplitCount    int      `json:"split_count"`
+	PropsFilename string   `json:"props_filename"`
+	MetadataProps []string `json:"metadata_props"`
+	SkipDownload  bool     `json:"skip_download"`
+	// IncludeSuffixes and ExcludeSuffixes filter files downloaded from a group of files
+	IncludeSuffixes []string `json:"include_suffixes"`
+	ExcludeSuffixes []string `json:"exclude_suffixes"`
+	Unpack          bool     `json:"unpack"`
+	StripComponents int      `json:"strip_components"`
+	UnpackDir       string   `json:"unpack_dir"`
+	DeleteArchive   bool     `json:"delete_archive"`
+	VerifySignature bool     `json:"verify_signature"`
 }
 
 type OutParams struct {
@@ -32,4 +77,122 @@ type OutParams struct {
 	ExplodeArchive bool   `json:"explode_archive"`
 	Props          string `json:"props"`
 	PropsFromFile  string `json:"props_from_file"`
+	// IfExists is what to do when a target already exists: fail, skip or overwrite (default)
+	IfExists string `json:"if_exists"`
+	// SyncDeletes is a path in artifactory where files which are not in source are deleted after upload
+	SyncDeletes string `json:"sync_deletes"`
+	// MaxDeletions fails the upload when sync deletes would delete more files, it defaults to 50
+	MaxDeletions int `json:"max_deletions"`
+	// Filename renames the uploaded file, like Target it can be a template
+	Filename    string `json:"filename"`
+	VersionFile string `json:"version_file"`
+	// Archive packs files matched by Source in a single zip or tgz named ArchiveName (which can be a template) before upload
+	Archive     string `json:"archive"`
+	ArchiveName string `json:"archive_name"`
+	// BuildName and BuildNumber default to concourse pipeline/job names and build name
+	BuildName        string `json:"build_name"`
+	BuildNumber      string `json:"build_number"`
+	PublishBuildInfo bool   `json:"publish_build_info"`
+	// DryRun only logs and gives in metadata operations which would be done, nothing is written in artifactory
+	DryRun bool `json:"dry_run"`
+	// Promote makes put promote a build instead of uploading files
+	Promote *PromoteParams `json:"promote"`
+	// Copy and Move make put copy or move the file of a prior get instead of uploading files
+	Copy *CopyParams `json:"copy"`
+	Move *CopyParams `json:"move"`
+	// Cleanup makes put delete old files instead of uploading files
+	Cleanup *CleanupParams `json:"cleanup"`
+	// Properties makes put set or delete properties on files already in artifactory instead of uploading files
+	Properties *PropertiesParams `json:"properties"`
+}
+
+// PropertiesParams defines properties set or deleted on the file of a prior get or on files found by a pattern.
+type PropertiesParams struct {
+	// From is the folder of the prior get, only one of from or pattern can be set
+	From    string `json:"from"`
+	Pattern string `json:"pattern"`
+	// SetProps are set in the form of key1=value1;key2=value2
+	SetProps string `json:"set_props"`
+	// DeleteProps are keys of properties deleted in the form of key1,key2
+	DeleteProps string `json:"delete_props"`
+	// Recursive applies properties to folders and everything they contain
+	Recursive bool `json:"recursive"`
+}
+
+// CleanupParams defines which files are deleted by a cleanup, files are sorted as check does with source order_by.
+type CleanupParams struct {
+	// Pattern of files to clean up, it defaults to source pattern
+	Pattern string `json:"pattern"`
+	// KeepLast is the number of newest files always kept
+	KeepLast int `json:"keep_last"`
+	// OlderThan keeps files modified more recently (e.g.: 30d, 12h)
+	OlderThan string `json:"older_than"`
+	// ExcludeProps keeps files which have one of these properties in the form of key1=value1;key2=value2
+	ExcludeProps string `json:"exclude_props"`
+	// DryRun defaults to true, files are only deleted when it is explicitly set to false
+	DryRun *bool `json:"dry_run"`
+	// MaxDeletions fails the cleanup when more files would be deleted, it defaults to 50
+	MaxDeletions int `json:"max_deletions"`
+}
+
+// CopyParams defines where the file of a prior get is copied or moved in artifactory.
+type CopyParams struct {
+	// From is the folder of the prior get
+	From string `json:"from"`
+	// Target is a path in the form of [repository_name]/[repository_path], file name is kept when it ends with a slash
+	Target string `json:"target"`
+	// Props are added to the copied file in the form of key1=value1;key2=value2
+	Props string `json:"props"`
+}
+
+// PromoteParams defines a build promotion, see https://www.jfrog.com/confluence/display/JFROG/Artifactory+REST+API#ArtifactoryRESTAPI-BuildPromotion
+type PromoteParams struct {
+	BuildName           string `json:"build_name"`
+	BuildNumber         string `json:"build_number"`
+	SourceRepo          string `json:"source_repo"`
+	TargetRepo          string `json:"target_repo"`
+	Status              string `json:"status"`
+	Comment             string `json:"comment"`
+	Copy                bool   `json:"copy"`
+	IncludeDependencies bool   `json:"include_dependencies"`
+	DryRun              bool   `json:"dry_run"`
+}
+
+type Version struct {
+	BuildNumber string `json:"build,omitempty"`
+	Path        string `json:"path,omitempty"`
+	Sha256      string `json:"sha256,omitempty"`
+	Modified    string `json:"modified,omitempty"`
+	Semver      string `json:"semver,omitempty"`
+	// Files are artifactory paths, separated by comma, of all files of a group when files are grouped
+	Files string `json:"files,omitempty"`
+	// Placeholder is the reason why a version emitted by put doesn't point to a file in artifactory (e.g.: dry_run),
+	// get does nothing with such version
+	Placeholder string `json:"placeholder,omitempty"`
+}
+
+const (
+	PLACEHOLDER_DRY_RUN = "dry_run"
+	PLACEHOLDER_NO_FILE = "no_file"
+)
+
+// GroupFiles gives artifactory paths of files in the group, it is empty when version is not a group.
+func (v Version) GroupFiles() []string {
+	if v.Files == "" {
+		return []string{}
+	}
+	return strings.Split(v.Files, ",")
+}
+
+// FilePath gives the artifactory path of the version, versions made before multi-field versions only have a build field.
+func (v Version) FilePath() string {
+	if v.Path != "" {
+		return v.Path
+	}
+	return v.BuildNumber
+}
+
+type Response struct {
+	Metadata []chelper.Metadata `json:"metadata"`
+	Version  Version            `json:"version"`
 }
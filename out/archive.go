@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/mholt/archiver/v3"
+)
+
+const (
+	ARCHIVE_ZIP = "zip"
+	ARCHIVE_TGZ = "tgz"
+)
+
+// PackSource archives files and folders matched by src in a temporary folder, it gives the path of the archive
+// which is then uploaded instead of src and the first file or folder matched. Archive name is rendered as a template
+// with build vars and version, read from version_file or found in the name of the first file or folder matched.
+func (c Out) PackSource(src string) (string, string, error) {
+	sources, err := filepath.Glob(src)
+	if err != nil {
+		return "", "", err
+	}
+	if len(sources) == 0 {
+		return "", "", fmt.Errorf("No file found to archive with source '%s'", c.params.Source)
+	}
+	c.packedSource = sources[0]
+	data, err := c.templateData(nil)
+	if err != nil {
+		return "", "", err
+	}
+	archiveName, err := renderTemplate("archive_name", c.params.ArchiveName, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	var packer archiver.Archiver
+	switch c.params.Archive {
+	case ARCHIVE_ZIP:
+		packer = archiver.NewZip()
+		if !strings.HasSuffix(archiveName, ".zip") {
+			archiveName += ".zip"
+		}
+	case ARCHIVE_TGZ:
+		packer = archiver.NewTarGz()
+		if !strings.HasSuffix(archiveName, ".tgz") && !strings.HasSuffix(archiveName, ".tar.gz") {
+			archiveName += ".tgz"
+		}
+	default:
+		return "", "", fmt.Errorf("Unknown archive '%s', valid values are: %s, %s", c.params.Archive, ARCHIVE_ZIP, ARCHIVE_TGZ)
+	}
+	tmpDir, err := ioutil.TempDir("", "artifactory-archive")
+	if err != nil {
+		return "", "", err
+	}
+	archivePath := filepath.Join(tmpDir, archiveName)
+	err = packer.Archive(sources, archivePath)
+	if err != nil {
+		return "", "", err
+	}
+	return archivePath, sources[0], nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	fpath "path/filepath"
+	"strings"
+	"time"
+
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/jfrog/jfrog-client-go/artifactory/buildinfo"
+	clientutils "github.com/jfrog/jfrog-client-go/utils"
+)
+
+const BUILD_INFO_ENV_PREFIX = "buildInfo.env."
+
+// SENSITIVE_ENV_KEYS are parts of env var names which are never published in build info.
+var SENSITIVE_ENV_KEYS = []string{"password", "secret", "key", "token"}
+
+// BuildProps gives properties linking uploaded files to the build.
+func (c Out) BuildProps() string {
+	return fmt.Sprintf(
+		"build.name=%s;build.number=%s;build.timestamp=%d",
+		c.params.BuildName,
+		c.params.BuildNumber,
+		c.buildStarted.UnixNano()/int64(time.Millisecond),
+	)
+}
+
+// PublishBuildInfo publishes build info listing uploaded files with env and vcs of inputs.
+func (c Out) PublishBuildInfo(uploadedFiles []UploadedFile) error {
+	build := buildinfo.New()
+	build.Name = c.params.BuildName
+	build.Number = c.params.BuildNumber
+	build.Started = c.buildStarted.Format(buildinfo.TimeFormat)
+	build.SetAgentName("artifactory-resource")
+	build.BuildAgent.Name = "Concourse"
+	build.BuildUrl = concourseBuildUrl()
+	build.Properties = buildEnv()
+	build.VcsList = c.inputsVcs()
+
+	artifacts := make([]buildinfo.Artifact, 0)
+	for _, uploadedFile := range uploadedFiles {
+		artifacts = append(artifacts, buildinfo.Artifact{
+			Name: path.Base(uploadedFile.Path),
+			Type: strings.TrimPrefix(path.Ext(uploadedFile.Path), "."),
+			Path: uploadedFile.Path,
+			Checksum: &buildinfo.Checksum{
+				Sha1: uploadedFile.Checksums.Sha1,
+				Md5:  uploadedFile.Checksums.Md5,
+			},
+		})
+	}
+	build.Modules = []buildinfo.Module{
+		{
+			Type:      buildinfo.Generic,
+			Id:        c.params.BuildName,
+			Artifacts: artifacts,
+		},
+	}
+
+	servicesManager, err := artutils.CreateServiceManager(c.artdetails, 0, false)
+	if err != nil {
+		return err
+	}
+	_, err = servicesManager.PublishBuildInfo(build, "")
+	return err
+}
+
+// concourseBuildUrl gives url of the current build in concourse web ui.
+func concourseBuildUrl() string {
+	atcUrl := strings.TrimSuffix(os.Getenv("ATC_EXTERNAL_URL"), "/")
+	if atcUrl == "" {
+		return ""
+	}
+	if os.Getenv("BUILD_PIPELINE_NAME") == "" {
+		return fmt.Sprintf("%s/builds/%s", atcUrl, os.Getenv("BUILD_ID"))
+	}
+	return fmt.Sprintf(
+		"%s/teams/%s/pipelines/%s/jobs/%s/builds/%s",
+		atcUrl,
+		url.PathEscape(os.Getenv("BUILD_TEAM_NAME")),
+		url.PathEscape(os.Getenv("BUILD_PIPELINE_NAME")),
+		url.PathEscape(os.Getenv("BUILD_JOB_NAME")),
+		url.PathEscape(os.Getenv("BUILD_NAME")),
+	)
+}
+
+// buildEnv gives env vars of the put without the ones which look sensitive.
+func buildEnv() buildinfo.Env {
+	env := buildinfo.Env{}
+	for _, envVar := range os.Environ() {
+		parts := strings.SplitN(envVar, "=", 2)
+		if len(parts) != 2 || isSensitiveEnv(parts[0]) {
+			continue
+		}
+		env[BUILD_INFO_ENV_PREFIX+parts[0]] = parts[1]
+	}
+	return env
+}
+
+func isSensitiveEnv(name string) bool {
+	for _, sensitiveKey := range SENSITIVE_ENV_KEYS {
+		if strings.Contains(strings.ToLower(name), sensitiveKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// inputsVcs gives vcs details of inputs which are git repositories (e.g.: given by git resource).
+func (c Out) inputsVcs() []buildinfo.Vcs {
+	vcsList := make([]buildinfo.Vcs, 0)
+	inputs, err := os.ReadDir(c.cmd.SourceFolder())
+	if err != nil {
+		return vcsList
+	}
+	vcsCache := clientutils.NewVcsDetals()
+	for _, input := range inputs {
+		inputPath := fpath.Join(c.cmd.SourceFolder(), input.Name())
+		if _, err := os.Stat(fpath.Join(inputPath, ".git")); err != nil {
+			continue
+		}
+		revision, vcsUrl, branch, err := vcsCache.GetVcsDetails(inputPath)
+		if err != nil || revision == "" {
+			continue
+		}
+		vcsList = append(vcsList, buildinfo.Vcs{
+			Url:      vcsUrl,
+			Revision: revision,
+			Branch:   branch,
+		})
+	}
+	return vcsList
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	"github.com/jfrog/jfrog-cli-core/v2/artifactory/commands/generic"
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	rtutils "github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+const DEFAULT_MAX_DELETIONS = 50
+
+// RunCleanup deletes files found by pattern which are outside of the retention window,
+// files are found and sorted as check does. Nothing is deleted unless dry_run is explicitly set to false.
+// Version emitted is the newest file kept (or a placeholder when no file is kept).
+func (c Out) RunCleanup() {
+	msg := c.cmd.Messager()
+	params := *c.params.Cleanup
+	if params.KeepLast <= 0 && params.OlderThan == "" {
+		msg.Fatal("You must set keep_last and/or older_than to clean up files.")
+	}
+	if params.KeepLast < 0 {
+		msg.Fatal("keep_last can't be negative.")
+	}
+	dryRun := c.params.DryRun || params.DryRun == nil || *params.DryRun
+	maxDeletions := params.MaxDeletions
+	if maxDeletions <= 0 {
+		maxDeletions = DEFAULT_MAX_DELETIONS
+	}
+	var olderThan time.Duration
+	if params.OlderThan != "" {
+		var err error
+		olderThan, err = parseAge(params.OlderThan)
+		msg.FatalIf("Error when parsing older_than", err)
+	}
+	var excludeProps *rtutils.Properties
+	if params.ExcludeProps != "" {
+		var err error
+		excludeProps, err = rtutils.ParseProperties(params.ExcludeProps)
+		msg.FatalIf("Error when parsing exclude_props", err)
+	}
+	err := utils.CheckVersionStrategy(c.source.VersionStrategy)
+	msg.FatalIf("Error in version_strategy", err)
+	if params.Pattern == "" && c.source.Query != nil && excludeProps != nil && !utils.AqlQueryIncludesProps(*c.source.Query) {
+		msg.Fatal("Properties can't be retrieved in a sorted or limited query, you can't use exclude_props with sort or limit in query.")
+	}
+
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	var results []utils.SearchResult
+	if params.Pattern == "" && c.source.Query != nil {
+		results, err = utils.SearchAql(c.artdetails, *c.source.Query)
+	} else {
+		results, err = c.searchPattern(c.cleanupPattern(params.Pattern))
+	}
+	os.Stdout = origStdout
+	msg.FatalIf("Error when searching files to clean up", err)
+	sortedResults, err := utils.SortResults(msg, results, c.source.OrderBy, c.source.VersionStrategy)
+	msg.FatalIf("Error when sorting files to clean up", err)
+
+	kept, toDelete := splitRetention(sortedResults, params.KeepLast, olderThan, excludeProps, time.Now())
+
+	operations := make([]PlannedOperation, 0)
+	for _, file := range toDelete {
+		operations = append(operations, PlannedOperation{
+			Operation: "delete",
+			Source:    file.Path,
+		})
+	}
+	msg.Logln("[blue]Cleanup preview[reset]: %d file(s) found, %d kept, %d to delete", len(sortedResults), len(kept), len(toDelete))
+	if dryRun {
+		c.logPlan(operations)
+	} else {
+		for _, file := range toDelete {
+			msg.Logln("  - [red]%s[reset] (modified: %s)", file.Path, file.Modified)
+		}
+	}
+	if len(toDelete) > maxDeletions {
+		msg.Fatal(fmt.Sprintf("Cleanup would delete %d files which is more than max_deletions (%d), nothing has been deleted.", len(toDelete), maxDeletions))
+	}
+
+	totalDeleted := 0
+	if !dryRun && len(toDelete) > 0 {
+		os.Stdout = os.Stderr
+		totalDeleted, err = c.deleteFiles(toDelete)
+		os.Stdout = origStdout
+		msg.FatalIf("Error when deleting files", err)
+		msg.Logln("[blue]Deleted[reset] %d file(s).", totalDeleted)
+	}
+
+	metadata := []chelper.Metadata{
+		{
+			Name:  "total_found",
+			Value: fmt.Sprintf("%d", len(sortedResults)),
+		},
+		{
+			Name:  "total_kept",
+			Value: fmt.Sprintf("%d", len(kept)),
+		},
+		{
+			Name:  "total_deleted",
+			Value: fmt.Sprintf("%d", totalDeleted),
+		},
+		{
+			Name:  "dry_run",
+			Value: strconv.FormatBool(dryRun),
+		},
+	}
+	if dryRun {
+		metadata = append(metadata, planMetadata(operations)...)
+	} else {
+		for _, file := range toDelete {
+			metadata = append(metadata, chelper.Metadata{
+				Name:  "deleted_file",
+				Value: file.Path,
+			})
+		}
+	}
+
+	version := model.Version{
+		Path:        c.cleanupPattern(params.Pattern),
+		Placeholder: model.PLACEHOLDER_NO_FILE,
+	}
+	if len(kept) > 0 {
+		version, err = c.VersionFromArtifactory(kept[len(kept)-1].Path)
+		msg.FatalIf("Error when retrieving version", err)
+	}
+	json.NewEncoder(os.Stdout).Encode(model.Response{
+		Metadata: metadata,
+		Version:  version,
+	})
+}
+
+func (c Out) cleanupPattern(pattern string) string {
+	if pattern == "" {
+		return c.source.Pattern
+	}
+	return pattern
+}
+
+// searchPattern finds files with pattern and source props as check does.
+func (c Out) searchPattern(pattern string) ([]utils.SearchResult, error) {
+	if pattern == "" {
+		return nil, errors.New("You must set a pattern in cleanup or in source to clean up files.")
+	}
+	searchSpec := spec.NewBuilder().
+		Pattern(pattern).
+		Props(c.source.Props).
+		Regexp(c.source.Regexp).
+		Recursive(c.source.Recursive).
+		BuildSpec()
+	return utils.Search(c.artdetails, searchSpec)
+}
+
+// deleteFiles deletes files by their exact path, it gives the number of files deleted.
+func (c Out) deleteFiles(files []utils.SearchResult) (int, error) {
+	deleteSpec := &spec.SpecFiles{}
+	for _, file := range files {
+		deleteSpec.Files = append(deleteSpec.Files, *spec.NewBuilder().
+			Pattern(file.Path).
+			Recursive(false).
+			BuildSpec().Get(0))
+	}
+	cmd := generic.NewDeleteCommand()
+	cmd.SetThreads(c.params.Threads)
+	cmd.SetQuiet(true)
+	cmd.SetServerDetails(c.artdetails).SetSpec(deleteSpec)
+	err := cmd.Run()
+	return cmd.Result().SuccessCount(), err
+}
+
+// splitRetention splits files sorted from oldest to newest in files kept and files to delete,
+// a file is kept when it is one of the keep_last newest, when it is not older than older_than or when it has one of exclude_props.
+func splitRetention(sortedFiles []utils.SearchResult, keepLast int, olderThan time.Duration, excludeProps *rtutils.Properties, now time.Time) ([]utils.SearchResult, []utils.SearchResult) {
+	kept := make([]utils.SearchResult, 0)
+	toDelete := make([]utils.SearchResult, 0)
+	retainedFrom := len(sortedFiles) - keepLast
+	for i, file := range sortedFiles {
+		switch {
+		case keepLast > 0 && i >= retainedFrom:
+			kept = append(kept, file)
+		case olderThan > 0 && !isOlderThan(file.Modified, now.Add(-olderThan)):
+			kept = append(kept, file)
+		case excludeProps != nil && hasOneOfProps(file.Props, excludeProps):
+			kept = append(kept, file)
+		default:
+			toDelete = append(toDelete, file)
+		}
+	}
+	return kept, toDelete
+}
+
+// parseAge parses a duration which can be expressed in days (e.g.: 30d) in addition to go durations.
+func parseAge(age string) (time.Duration, error) {
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("Invalid age '%s', it must be a number of days (e.g.: 30d) or a duration (e.g.: 12h)", age)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(age)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("Invalid age '%s', it must be a number of days (e.g.: 30d) or a duration (e.g.: 12h)", age)
+	}
+	return duration, nil
+}
+
+// isOlderThan tells if an artifactory date is before limit, a date which can't be parsed is never older.
+func isOlderThan(date string, limit time.Time) bool {
+	dateTime, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return false
+	}
+	return dateTime.Before(limit)
+}
+
+func hasOneOfProps(fileProps map[string][]string, props *rtutils.Properties) bool {
+	for key, values := range props.ToMap() {
+		for _, value := range fileProps[key] {
+			for _, excluded := range values {
+				if value == excluded {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	rtutils "github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+func cleanupFile(path string, modified string, props map[string][]string) utils.SearchResult {
+	return utils.SearchResult{
+		SearchResult: artutils.SearchResult{
+			Path:     path,
+			Modified: modified,
+			Props:    props,
+		},
+	}
+}
+
+func filePaths(files []utils.SearchResult) []string {
+	paths := make([]string, 0)
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+func TestSplitRetention(t *testing.T) {
+	now := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	// sorted from oldest to newest
+	files := []utils.SearchResult{
+		cleanupFile("repo/app-1.0.0.tgz", "2021-01-01T00:00:00.000Z", map[string][]string{"release": {"true"}}),
+		cleanupFile("repo/app-1.1.0.tgz", "2021-02-01T00:00:00.000Z", nil),
+		cleanupFile("repo/app-1.2.0.tgz", "not a date", nil),
+		cleanupFile("repo/app-1.3.0.tgz", "2021-02-20T00:00:00.000Z", map[string][]string{"release": {"false"}}),
+		cleanupFile("repo/app-1.4.0.tgz", "2021-02-28T00:00:00.000Z", nil),
+	}
+	release, err := rtutils.ParseProperties("release=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		files        []utils.SearchResult
+		keepLast     int
+		olderThan    time.Duration
+		excludeProps *rtutils.Properties
+		kept         []string
+		toDelete     []string
+	}{
+		{
+			name:     "keep last",
+			files:    files,
+			keepLast: 2,
+			kept:     []string{"repo/app-1.3.0.tgz", "repo/app-1.4.0.tgz"},
+			toDelete: []string{"repo/app-1.0.0.tgz", "repo/app-1.1.0.tgz", "repo/app-1.2.0.tgz"},
+		},
+		{
+			name:     "keep last as many as files",
+			files:    files,
+			keepLast: 5,
+			kept:     []string{"repo/app-1.0.0.tgz", "repo/app-1.1.0.tgz", "repo/app-1.2.0.tgz", "repo/app-1.3.0.tgz", "repo/app-1.4.0.tgz"},
+			toDelete: []string{},
+		},
+		{
+			name:     "keep last more than files",
+			files:    files,
+			keepLast: 10,
+			kept:     []string{"repo/app-1.0.0.tgz", "repo/app-1.1.0.tgz", "repo/app-1.2.0.tgz", "repo/app-1.3.0.tgz", "repo/app-1.4.0.tgz"},
+			toDelete: []string{},
+		},
+		{
+			// file with unparseable date is never older
+			name:      "older than",
+			files:     files,
+			olderThan: 14 * 24 * time.Hour,
+			kept:      []string{"repo/app-1.2.0.tgz", "repo/app-1.3.0.tgz", "repo/app-1.4.0.tgz"},
+			toDelete:  []string{"repo/app-1.0.0.tgz", "repo/app-1.1.0.tgz"},
+		},
+		{
+			name:      "older than everything",
+			files:     files,
+			olderThan: 365 * 24 * time.Hour,
+			kept:      []string{"repo/app-1.0.0.tgz", "repo/app-1.1.0.tgz", "repo/app-1.2.0.tgz", "repo/app-1.3.0.tgz", "repo/app-1.4.0.tgz"},
+			toDelete:  []string{},
+		},
+		{
+			// a file is kept when any criteria keeps it
+			name:      "keep last and older than",
+			files:     files,
+			keepLast:  1,
+			olderThan: 20 * 24 * time.Hour,
+			kept:      []string{"repo/app-1.2.0.tgz", "repo/app-1.3.0.tgz", "repo/app-1.4.0.tgz"},
+			toDelete:  []string{"repo/app-1.0.0.tgz", "repo/app-1.1.0.tgz"},
+		},
+		{
+			name:         "keep last and exclude props",
+			files:        files,
+			keepLast:     1,
+			excludeProps: release,
+			kept:         []string{"repo/app-1.0.0.tgz", "repo/app-1.4.0.tgz"},
+			toDelete:     []string{"repo/app-1.1.0.tgz", "repo/app-1.2.0.tgz", "repo/app-1.3.0.tgz"},
+		},
+		{
+			name:         "all criteria",
+			files:        files,
+			keepLast:     1,
+			olderThan:    20 * 24 * time.Hour,
+			excludeProps: release,
+			kept:         []string{"repo/app-1.0.0.tgz", "repo/app-1.2.0.tgz", "repo/app-1.3.0.tgz", "repo/app-1.4.0.tgz"},
+			toDelete:     []string{"repo/app-1.1.0.tgz"},
+		},
+		{
+			name:     "no file",
+			files:    []utils.SearchResult{},
+			keepLast: 2,
+			kept:     []string{},
+			toDelete: []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kept, toDelete := splitRetention(test.files, test.keepLast, test.olderThan, test.excludeProps, now)
+			if paths := filePaths(kept); !reflect.DeepEqual(paths, test.kept) {
+				t.Errorf("kept %v, expected %v", paths, test.kept)
+			}
+			if paths := filePaths(toDelete); !reflect.DeepEqual(paths, test.toDelete) {
+				t.Errorf("to delete %v, expected %v", paths, test.toDelete)
+			}
+		})
+	}
+}
+
+// files without semver are not sorted so they are never deleted
+func TestSplitRetentionSkipsFilesWithoutSemver(t *testing.T) {
+	files := []utils.SearchResult{
+		cleanupFile("repo/app-latest.tgz", "2020-01-01T00:00:00.000Z", nil),
+		cleanupFile("repo/app-1.10.0.tgz", "2021-01-01T00:00:00.000Z", nil),
+		cleanupFile("repo/app-1.9.0.tgz", "2021-01-02T00:00:00.000Z", nil),
+		cleanupFile("repo/app-1.2.0.tgz", "2021-01-03T00:00:00.000Z", nil),
+	}
+	msg := &chelper.Messager{LogWriter: ioutil.Discard}
+	sortedFiles, err := utils.SortResults(msg, files, utils.ORDER_BY_SEMVER, model.VersionStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, toDelete := splitRetention(sortedFiles, 1, 0, nil, time.Now())
+	if paths := filePaths(kept); !reflect.DeepEqual(paths, []string{"repo/app-1.10.0.tgz"}) {
+		t.Errorf("kept %v, expected newest semver", paths)
+	}
+	if paths := filePaths(toDelete); !reflect.DeepEqual(paths, []string{"repo/app-1.2.0.tgz", "repo/app-1.9.0.tgz"}) {
+		t.Errorf("to delete %v, expected older semvers only", paths)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		age      string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{age: "30d", expected: 30 * 24 * time.Hour},
+		{age: "1d", expected: 24 * time.Hour},
+		{age: "12h", expected: 12 * time.Hour},
+		{age: "90m", expected: 90 * time.Minute},
+		{age: "0d", wantErr: true},
+		{age: "-1d", wantErr: true},
+		{age: "-1h", wantErr: true},
+		{age: "0s", wantErr: true},
+		{age: "d", wantErr: true},
+		{age: "1w", wantErr: true},
+		{age: "", wantErr: true},
+	}
+	for _, test := range tests {
+		age, err := parseAge(test.age)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseAge(%q) should fail, got %s", test.age, age)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseAge(%q) failed: %s", test.age, err)
+			continue
+		}
+		if age != test.expected {
+			t.Errorf("parseAge(%q) = %s, expected %s", test.age, age, test.expected)
+		}
+	}
+}
+
+func TestIsOlderThan(t *testing.T) {
+	limit := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		date     string
+		expected bool
+	}{
+		{date: "2021-02-28T23:59:59.000Z", expected: true},
+		{date: "2021-03-01T00:00:00.000Z", expected: false},
+		{date: "2021-03-01T01:00:00.000+02:00", expected: true},
+		{date: "2021-03-02T00:00:00.000Z", expected: false},
+		{date: "", expected: false},
+		{date: "yesterday", expected: false},
+	}
+	for _, test := range tests {
+		if older := isOlderThan(test.date, limit); older != test.expected {
+			t.Errorf("isOlderThan(%q) = %t, expected %t", test.date, older, test.expected)
+		}
+	}
+}
+
+func TestHasOneOfProps(t *testing.T) {
+	props, err := rtutils.ParseProperties("release=true;keep=forever,always")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		fileProps map[string][]string
+		expected  bool
+	}{
+		{name: "matching prop", fileProps: map[string][]string{"release": {"true"}}, expected: true},
+		{name: "one of values", fileProps: map[string][]string{"keep": {"always"}}, expected: true},
+		{name: "one of file values", fileProps: map[string][]string{"release": {"false", "true"}}, expected: true},
+		{name: "other value", fileProps: map[string][]string{"release": {"false"}}, expected: false},
+		{name: "other key", fileProps: map[string][]string{"status": {"true"}}, expected: false},
+		{name: "no props", fileProps: nil, expected: false},
+	}
+	for _, test := range tests {
+		if has := hasOneOfProps(test.fileProps, props); has != test.expected {
+			t.Errorf("%s: hasOneOfProps = %t, expected %t", test.name, has, test.expected)
+		}
+	}
+}
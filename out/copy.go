@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	"github.com/jfrog/jfrog-cli-core/v2/artifactory/commands/generic"
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// RunCopy copies or moves the file of a prior get to target, properties of the file are kept.
+// Version emitted is the new path of the file.
+func (c Out) RunCopy() {
+	msg := c.cmd.Messager()
+	if c.params.Copy != nil && c.params.Move != nil {
+		msg.Fatal("You must set only one of copy or move.")
+	}
+	params, move, action, logAction := c.params.Copy, false, "copied", "Copying"
+	if c.params.Move != nil {
+		params, move, action, logAction = c.params.Move, true, "moved", "Moving"
+	}
+	if params.From == "" || params.Target == "" {
+		msg.Fatal("You must set from (folder of a prior get) and target (in the form of: [repository_name]/[repository_path]).")
+	}
+
+	// path and version files are written by in
+	fromFolder := utils.AddTrailingSlashIfNeeded(c.folderPath(params.From))
+	content, err := ioutil.ReadFile(fromFolder + "path")
+	msg.FatalIf(fmt.Sprintf("Error when reading artifactory path of file from '%s'", params.From), err)
+	srcPath := strings.TrimSpace(string(content))
+	target, err := c.renderCopyTarget(params.Target, fromFolder)
+	msg.FatalIf("Error when rendering target", err)
+	newPath := target
+	if strings.HasSuffix(target, "/") {
+		newPath += path.Base(srcPath)
+	}
+
+	copySpec := spec.NewBuilder().
+		Pattern(srcPath).
+		Target(target).
+		Recursive(false).
+		Flat(true).
+		BuildSpec()
+	msg.Log("[blue]%s[reset] file '[blue]%s[reset]' to '[blue]%s[reset]'...", logAction, srcPath, newPath)
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	total, err := c.runMoveCopy(copySpec, move)
+	if err == nil && params.Props != "" && !c.params.DryRun {
+		propsSpec := spec.NewBuilder().Pattern(newPath).Recursive(false).BuildSpec()
+		_, err = c.SetProps(propsSpec, params.Props)
+	}
+	os.Stdout = origStdout
+	msg.FatalIf(fmt.Sprintf("Error when file is %s", action), err)
+	if total == 0 {
+		msg.Fatal(fmt.Sprintf("File '%s' not found in artifactory", srcPath))
+	}
+
+	if c.params.DryRun {
+		operation := "copy"
+		if move {
+			operation = "move"
+		}
+		operations := []PlannedOperation{
+			{
+				Operation: operation,
+				Source:    srcPath,
+				Target:    newPath,
+				Props:     params.Props,
+			},
+		}
+		c.logPlan(operations)
+		// file is not in target on a dry run, version is a placeholder of the file to copy at its new path
+		version, err := c.VersionFromArtifactory(srcPath)
+		msg.FatalIf("Error when retrieving version", err)
+		version.Path = newPath
+		version.Placeholder = model.PLACEHOLDER_DRY_RUN
+		json.NewEncoder(os.Stdout).Encode(model.Response{
+			Metadata: append(dryRunMetadata(), planMetadata(operations)...),
+			Version:  version,
+		})
+		return
+	}
+
+	version, err := c.VersionFromArtifactory(newPath)
+	msg.FatalIf("Error when retrieving new version", err)
+	json.NewEncoder(os.Stdout).Encode(model.Response{
+		Metadata: []chelper.Metadata{
+			{
+				Name:  action + "_from",
+				Value: srcPath,
+			},
+			{
+				Name:  action + "_to",
+				Value: newPath,
+			},
+		},
+		Version: version,
+	})
+}
+
+// renderCopyTarget renders target as a template with build vars and version found by the prior get.
+func (c Out) renderCopyTarget(target string, fromFolder string) (string, error) {
+	if !strings.Contains(target, "{{") {
+		return target, nil
+	}
+	data, err := c.templateData(nil)
+	if err != nil {
+		return "", err
+	}
+	if content, err := ioutil.ReadFile(fromFolder + "version"); err == nil {
+		data["Version"] = strings.TrimSpace(string(content))
+	}
+	return renderTemplate("target", target, data)
+}
+
+func (c Out) runMoveCopy(moveCopySpec *spec.SpecFiles, move bool) (int, error) {
+	if move {
+		cmd := generic.NewMoveCommand()
+		cmd.SetThreads(c.params.Threads)
+		cmd.SetServerDetails(c.artdetails).SetSpec(moveCopySpec).SetDryRun(c.params.DryRun)
+		err := cmd.Run()
+		return cmd.Result().SuccessCount(), err
+	}
+	cmd := generic.NewCopyCommand()
+	cmd.SetThreads(c.params.Threads)
+	cmd.SetServerDetails(c.artdetails).SetSpec(moveCopySpec).SetDryRun(c.params.DryRun)
+	err := cmd.Run()
+	return cmd.Result().SuccessCount(), err
+}
+
+// VersionFromArtifactory gives version of a file already in artifactory in the same form as check does.
+func (c Out) VersionFromArtifactory(filePath string) (model.Version, error) {
+	info, err := utils.RetrieveStorageInfo(c.artdetails, c.source.CACert, filePath)
+	if err != nil {
+		return model.Version{}, err
+	}
+	props, err := utils.RetrieveStorageProperties(c.artdetails, c.source.CACert, filePath)
+	if err != nil {
+		return model.Version{}, err
+	}
+	version := model.Version{
+		Path:     filePath,
+		Sha256:   info.Checksums.Sha256,
+		Modified: info.LastModified,
+	}
+	semverFound, err := utils.ExtractSemver(c.source.VersionStrategy, filePath, props)
+	if err == nil {
+		version.Semver = semverFound.String()
+	}
+	return version, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	rtutils "github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+const (
+	TRANSFER_UPLOADED     = "uploaded"
+	TRANSFER_DEDUPLICATED = "deduplicated"
+	TRANSFER_SKIPPED      = "skipped"
+)
+
+const (
+	IF_EXISTS_OVERWRITE = "overwrite"
+	IF_EXISTS_FAIL      = "fail"
+	IF_EXISTS_SKIP      = "skip"
+)
+
+// DeployByChecksum puts a file in artifactory without sending its content, it gives:
+//   - skipped when target already holds the same content and there is no props to set
+//   - deduplicated when artifactory already knows the content and deployed it by checksum
+//   - an empty transfer when file must be uploaded
+func (c Out) DeployByChecksum(file UploadedFile) (string, error) {
+	props := c.mergeProps()
+	if props == "" {
+		found, sameContent, err := c.findTarget(file)
+		if err != nil {
+			return "", err
+		}
+		if found && sameContent {
+			return TRANSFER_SKIPPED, nil
+		}
+	}
+
+	deployUrl := file.Url
+	parsedProps, err := rtutils.ParseProperties(props)
+	if err != nil {
+		return "", err
+	}
+	if encodedProps := parsedProps.ToEncodedString(false); encodedProps != "" {
+		deployUrl += ";" + encodedProps
+	}
+	req, err := http.NewRequest("PUT", deployUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	utils.SetAuthentication(req, c.artdetails)
+	req.Header.Set("X-Checksum-Deploy", "true")
+	req.Header.Set("X-Checksum-Sha1", file.Checksums.Sha1)
+	req.Header.Set("X-Checksum-Sha256", file.Checksums.Sha256)
+	req.Header.Set("X-Checksum", file.Checksums.Md5)
+	client, err := utils.NewHttpClient(c.source.CACert)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	// artifactory answers not found when it doesn't know the content
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", nil
+	}
+	return TRANSFER_DEDUPLICATED, nil
+}
+
+// findTarget tells if target of a file already exists in artifactory and if it holds the same content (same sha1 and sha256).
+func (c Out) findTarget(file UploadedFile) (found bool, sameContent bool, err error) {
+	info, found, err := utils.FindStorageInfo(c.artdetails, c.source.CACert, file.Path)
+	if err != nil || !found {
+		return false, false, err
+	}
+	return true, info.Checksums.Sha1 == file.Checksums.Sha1 && info.Checksums.Sha256 == file.Checksums.Sha256, nil
+}
+
+// checkExistingTargets applies if_exists on planned files before anything is sent to artifactory,
+// it gives files to put in artifactory and files skipped because their target already holds the same content.
+func (c Out) checkExistingTargets(plannedFiles []UploadedFile) ([]UploadedFile, []UploadedFile, error) {
+	if c.params.IfExists == IF_EXISTS_OVERWRITE {
+		return plannedFiles, []UploadedFile{}, nil
+	}
+	filesToPut := make([]UploadedFile, 0)
+	skippedFiles := make([]UploadedFile, 0)
+	for _, plannedFile := range plannedFiles {
+		found, sameContent, err := c.findTarget(plannedFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch {
+		case !found:
+			filesToPut = append(filesToPut, plannedFile)
+		case c.params.IfExists == IF_EXISTS_FAIL:
+			return nil, nil, fmt.Errorf("File '%s' already exists in artifactory (if_exists: %s)", plannedFile.Path, IF_EXISTS_FAIL)
+		case !sameContent:
+			return nil, nil, fmt.Errorf("File '%s' already exists in artifactory with a different content (if_exists: %s)", plannedFile.Path, IF_EXISTS_SKIP)
+		default:
+			plannedFile.Transfer = TRANSFER_SKIPPED
+			skippedFiles = append(skippedFiles, plannedFile)
+		}
+	}
+	return filesToPut, skippedFiles, nil
+}
+
+// filesSpec gives a spec uploading each file to its exact target.
+func (c Out) filesSpec(files []UploadedFile) *spec.SpecFiles {
+	props := c.mergeProps()
+	filesSpec := &spec.SpecFiles{}
+	for _, file := range files {
+		fileSpec := spec.NewBuilder().
+			Pattern(file.LocalPath).
+			Target(file.Path).
+			Props(props).
+			Regexp(false).
+			Recursive(false).
+			Flat(true).
+			BuildSpec()
+		filesSpec.Files = append(filesSpec.Files, fileSpec.Files...)
+	}
+	return filesSpec
+}
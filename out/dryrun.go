@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// PlannedOperation is an operation put would do on artifactory without dry run.
+type PlannedOperation struct {
+	// Operation is the kind of operation (e.g.: upload, copy, delete)
+	Operation string
+	Source    string
+	Target    string
+	Props     string
+}
+
+// logPlan logs planned operations as a table.
+func (c Out) logPlan(operations []PlannedOperation) {
+	msg := c.cmd.Messager()
+	buf := &bytes.Buffer{}
+	table := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "OPERATION\tSOURCE\tTARGET\tPROPS")
+	for _, op := range operations {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", op.Operation, orDash(op.Source), orDash(op.Target), orDash(op.Props))
+	}
+	table.Flush()
+	msg.Logln("[yellow]Dry run[reset]: %d operation(s) planned, nothing has been written in artifactory.", len(operations))
+	msg.Logln("%s", buf.String())
+}
+
+// planMetadata gives planned operations as metadata, each one is named after its operation (e.g.: planned_upload).
+func planMetadata(operations []PlannedOperation) []chelper.Metadata {
+	metadata := []chelper.Metadata{
+		{
+			Name:  "total_planned",
+			Value: fmt.Sprintf("%d", len(operations)),
+		},
+	}
+	for _, op := range operations {
+		value := op.Source
+		if op.Source != "" && op.Target != "" {
+			value += " -> "
+		}
+		value += op.Target
+		if op.Props != "" {
+			value += fmt.Sprintf(" (props: %s)", op.Props)
+		}
+		metadata = append(metadata, chelper.Metadata{
+			Name:  "planned_" + op.Operation,
+			Value: value,
+		})
+	}
+	return metadata
+}
+
+func dryRunMetadata() []chelper.Metadata {
+	return []chelper.Metadata{
+		{
+			Name:  "dry_run",
+			Value: "true",
+		},
+	}
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// RunUploadDryRun plans upload of files found in source without uploading them,
+// version emitted is a placeholder of the primary file as it would be uploaded.
+func (c Out) RunUploadDryRun() {
+	msg := c.cmd.Messager()
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	plannedFiles, _, err := c.runUpload(c.spec, true)
+	os.Stdout = origStdout
+	msg.FatalIf("Error when planning upload", err)
+	if len(plannedFiles) == 0 {
+		msg.Fatal(fmt.Sprintf("No file found to upload with source '%s'", c.params.Source))
+	}
+	plannedFiles = sortUploadedFiles(plannedFiles)
+	_, skippedFiles, err := c.checkExistingTargets(plannedFiles)
+	msg.FatalIf("Error when checking existing files", err)
+	skipped := make(map[string]bool)
+	for _, skippedFile := range skippedFiles {
+		skipped[skippedFile.Path] = true
+	}
+
+	props := c.mergeProps()
+	operations := make([]PlannedOperation, 0)
+	for _, plannedFile := range plannedFiles {
+		operation := PlannedOperation{
+			Operation: "upload",
+			Source:    strings.TrimPrefix(plannedFile.LocalPath, utils.AddTrailingSlashIfNeeded(c.cmd.SourceFolder())),
+			Target:    plannedFile.Path,
+			Props:     props,
+		}
+		if c.params.Archive != "" {
+			operation.Source = fmt.Sprintf("%s (%s)", c.params.Source, c.params.Archive)
+		}
+		if skipped[plannedFile.Path] {
+			operation.Operation = "skip"
+			operation.Props = ""
+		}
+		operations = append(operations, operation)
+	}
+	if c.params.SyncDeletes != "" {
+		staleFiles, err := c.PlanSyncDeletes()
+		msg.FatalIf("Error when planning sync deletes", err)
+		for _, staleFile := range staleFiles {
+			operations = append(operations, PlannedOperation{
+				Operation: "delete",
+				Source:    staleFile.Path,
+			})
+		}
+	}
+	if c.params.PublishBuildInfo {
+		operations = append(operations, PlannedOperation{
+			Operation: "publish_build_info",
+			Target:    c.params.BuildName + "/" + c.params.BuildNumber,
+		})
+	}
+	c.logPlan(operations)
+
+	version, err := c.RetrieveVersion(plannedFiles[0])
+	msg.FatalIf("Error when retrieving planned version", err)
+	json.NewEncoder(os.Stdout).Encode(model.Response{
+		Metadata: append(dryRunMetadata(), planMetadata(operations)...),
+		Version:  version,
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// RetrieveGroupVersion gives the version of the group of the uploaded file as check gives it when files are grouped with group_by,
+// so put doesn't make a version check never gives. Version is kept as is when file is not in a group found by source.
+func (c Out) RetrieveGroupVersion(version model.Version) (model.Version, error) {
+	msg := c.cmd.Messager()
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	var results []utils.SearchResult
+	var err error
+	switch {
+	case c.source.Query != nil:
+		results, err = utils.SearchAql(c.artdetails, *c.source.Query)
+	case c.source.Pattern != "":
+		results, err = c.searchPattern(c.source.Pattern)
+	}
+	os.Stdout = origStdout
+	if err != nil {
+		return model.Version{}, err
+	}
+	grouped, err := utils.GroupResults(msg, results, c.source.GroupBy, c.source.VersionStrategy)
+	if err != nil {
+		return model.Version{}, err
+	}
+	for _, group := range grouped {
+		for _, groupFile := range group.Files {
+			if groupFile == version.Path {
+				return utils.ResultToVersion(group, c.source.VersionStrategy), nil
+			}
+		}
+	}
+	msg.Logln("[yellow]File[reset] '[blue]%s[reset]' is not in a group found by source, version emitted is not a group.", version.Path)
+	return version, nil
+}
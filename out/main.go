@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
 	"time"
 
 	"strings"
@@ -14,16 +17,31 @@ import (
 	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
 	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
 	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
+	rtutils "github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	clientutils "github.com/jfrog/jfrog-client-go/utils"
+	"github.com/jfrog/jfrog-client-go/utils/io/fileutils"
 	"github.com/orange-cloudfoundry/artifactory-resource/model"
 	"github.com/orange-cloudfoundry/artifactory-resource/utils"
 )
 
 type Out struct {
-	cmd        *chelper.OutCommand
-	source     model.Source
-	params     model.OutParams
-	artdetails *config.ServerDetails
-	spec       *spec.SpecFiles
+	cmd          *chelper.OutCommand
+	source       model.Source
+	params       model.OutParams
+	artdetails   *config.ServerDetails
+	spec         *spec.SpecFiles
+	buildStarted time.Time
+	// packedSource is the first file or folder put in archive when source is archived
+	packedSource string
+}
+
+type UploadedFile struct {
+	Path      string
+	Checksums fileutils.ChecksumDetails
+	LocalPath string
+	Url       string
+	// Transfer is how file was put in artifactory: uploaded, deduplicated or skipped
+	Transfer string
 }
 
 func main() {
@@ -36,6 +54,9 @@ func main() {
 func (c *Out) Run() {
 	cmd := c.cmd
 	msg := c.cmd.Messager()
+	// same defaults as check, they are used when cleaning up files
+	c.source.Recursive = true
+	c.source.OrderBy = utils.ORDER_BY_MODIFIED
 	err := cmd.Source(&c.source)
 
 	msg.FatalIf("Error when parsing source from concourse", err)
@@ -43,11 +64,24 @@ func (c *Out) Run() {
 
 	err = cmd.Params(&c.params)
 	msg.FatalIf("Error when parsing params from concourse", err)
-	if c.params.Target == "" {
-		msg.Fatal("You must set a target (in the form of: [repository_name]/[repository_path]) in out parameter.")
-	}
 
 	c.defaultingParams()
+	if c.params.PublishBuildInfo && (c.params.BuildName == "" || c.params.BuildNumber == "") {
+		msg.Fatal("You must set build_name and build_number to publish build info (they can't be found from concourse in a one-off build).")
+	}
+	if c.params.IfExists != IF_EXISTS_OVERWRITE && c.params.IfExists != IF_EXISTS_FAIL && c.params.IfExists != IF_EXISTS_SKIP {
+		msg.Fatal(fmt.Sprintf("Unknown if_exists '%s', valid values are: %s, %s, %s", c.params.IfExists, IF_EXISTS_FAIL, IF_EXISTS_SKIP, IF_EXISTS_OVERWRITE))
+	}
+	if c.params.IfExists != IF_EXISTS_OVERWRITE && c.params.ExplodeArchive {
+		msg.Fatal("You can't set if_exists with explode_archive, files of an exploded archive are always overwritten.")
+	}
+	if c.params.Archive != "" && (c.params.ArchiveName == "" || c.params.Filename != "" || c.params.ExplodeArchive) {
+		msg.Fatal("You must set archive_name with archive, and you can't set filename or explode_archive with it.")
+	}
+	if c.params.SyncDeletes != "" && (c.params.ExplodeArchive || c.params.IfExists == IF_EXISTS_SKIP) {
+		msg.Fatal("You can't set sync_deletes with explode_archive or if_exists skip, every file must be uploaded to be kept.")
+	}
+	c.buildStarted = time.Now()
 
 	err = utils.CheckReqParams(c.source)
 	if err != nil {
@@ -57,55 +91,209 @@ func (c *Out) Run() {
 	if err != nil && !strings.Contains(err.Error(), "You must provide a pattern") {
 		msg.Fatal(err.Error())
 	}
-	src := c.folderPath(c.params.Source)
-	target := utils.AddTrailingSlashIfNeeded(c.params.Target)
 
-	props := c.mergeProps()
+	// operations on files already in artifactory, nothing is uploaded
+	if c.params.Promote != nil {
+		c.RunPromote()
+		return
+	}
+	if c.params.Copy != nil || c.params.Move != nil {
+		c.RunCopy()
+		return
+	}
+	if c.params.Cleanup != nil {
+		c.RunCleanup()
+		return
+	}
+	if c.params.Properties != nil {
+		c.RunProperties()
+		return
+	}
 
-	builder := spec.NewBuilder()
-	c.spec = builder.
-		Pattern(src).
-		Target(target).
-		Props(props).
-		Regexp(c.source.Regexp).
-		Recursive(true).
-		Flat(true).
-		BuildSpec()
+	if c.params.Target == "" {
+		msg.Fatal("You must set a target (in the form of: [repository_name]/[repository_path]) in out parameter.")
+	}
+	src := c.folderPath(c.params.Source)
+	if c.params.Archive != "" {
+		src, c.packedSource, err = c.PackSource(src)
+		msg.FatalIf("Error when archiving source", err)
+		defer os.RemoveAll(filepath.Dir(src))
+		msg.Logln("[blue]Archived[reset] source '[blue]%s[reset]' in '[blue]%s[reset]'.", c.params.Source, filepath.Base(src))
+	}
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	target, err := c.RenderTarget(src)
+	os.Stdout = origStdout
+	msg.FatalIf("Error when rendering target", err)
+
+	c.spec = c.newSpec(src, target)
+	if c.params.DryRun {
+		c.RunUploadDryRun()
+		return
+	}
+	var staleFiles []utils.SearchResult
+	if c.params.SyncDeletes != "" {
+		staleFiles, err = c.PlanSyncDeletes()
+		msg.FatalIf("Error when planning sync deletes", err)
+	}
 
 	msg.Log("[blue]Uploading[reset] file(s) to target '[blue]%s[reset]'...", target)
 	startDl := time.Now()
-	origStdout := os.Stdout
 	os.Stdout = os.Stderr
-	totalUploaded, totalFailed, err := c.Upload()
+	uploadedFiles, totalFailed, err := c.Upload()
 	os.Stdout = origStdout
 	msg.FatalIf("Error when uploading", err)
 	if totalFailed > 0 {
 		msg.Fatal(fmt.Sprintf("%d files failed to upload", totalFailed))
 	}
+	if len(uploadedFiles) == 0 {
+		msg.Fatal(fmt.Sprintf("No file found to upload with source '%s'", c.params.Source))
+	}
 	elapsed := time.Since(startDl)
 	msg.Log("[blue]Finished uploading[reset] file(s) to target '[blue]%s[reset]'.", target)
 
-	json.NewEncoder(os.Stdout).Encode(chelper.Response{
-		Version: chelper.Version{
-			BuildNumber: src,
+	transferCounts := map[string]int{}
+	for _, uploadedFile := range uploadedFiles {
+		transferCounts[uploadedFile.Transfer]++
+	}
+	metadata := []chelper.Metadata{
+		{
+			Name:  "total_uploaded",
+			Value: fmt.Sprintf("%d", len(uploadedFiles)),
+		},
+		{
+			Name:  "total_transferred",
+			Value: fmt.Sprintf("%d", transferCounts[TRANSFER_UPLOADED]),
+		},
+		{
+			Name:  "total_deduplicated",
+			Value: fmt.Sprintf("%d", transferCounts[TRANSFER_DEDUPLICATED]),
+		},
+		{
+			Name:  "total_skipped",
+			Value: fmt.Sprintf("%d", transferCounts[TRANSFER_SKIPPED]),
 		},
-		Metadata: []chelper.Metadata{
-			{
-				Name:  "total_uploaded",
-				Value: fmt.Sprintf("%d", totalUploaded),
-			},
-			{
-				Name:  "upload_time",
-				Value: elapsed.String(),
-			},
+		{
+			Name:  "upload_time",
+			Value: elapsed.String(),
 		},
+	}
+	if c.params.SyncDeletes != "" {
+		metadata = append(metadata, chelper.Metadata{
+			Name:  "total_deleted",
+			Value: fmt.Sprintf("%d", len(staleFiles)),
+		})
+		for _, staleFile := range staleFiles {
+			metadata = append(metadata, chelper.Metadata{
+				Name:  "deleted_file",
+				Value: staleFile.Path,
+			})
+		}
+	}
+	uploadedName := "uploaded_file"
+	if c.params.ExplodeArchive {
+		// archive itself is not kept in artifactory
+		uploadedName = "exploded_archive"
+	}
+	for _, uploadedFile := range uploadedFiles {
+		msg.Logln("[blue]%s[reset]: %s", uploadedFile.Transfer, uploadedFile.Path)
+		metadata = append(metadata, chelper.Metadata{
+			Name: uploadedName,
+			Value: fmt.Sprintf(
+				"%s (sha256: %s, sha1: %s, md5: %s)",
+				uploadedFile.Path,
+				uploadedFile.Checksums.Sha256,
+				uploadedFile.Checksums.Sha1,
+				uploadedFile.Checksums.Md5,
+			),
+		})
+	}
+
+	if c.params.PublishBuildInfo {
+		msg.Log("[blue]Publishing[reset] build info '[blue]%s[reset]' number '[blue]%s[reset]'...", c.params.BuildName, c.params.BuildNumber)
+		os.Stdout = os.Stderr
+		err = c.PublishBuildInfo(uploadedFiles)
+		os.Stdout = origStdout
+		msg.FatalIf("Error when publishing build info", err)
+		metadata = append(metadata, chelper.Metadata{
+			Name:  "build_name",
+			Value: c.params.BuildName,
+		}, chelper.Metadata{
+			Name:  "build_number",
+			Value: c.params.BuildNumber,
+		})
+	}
+
+	version, err := c.RetrieveVersion(uploadedFiles[0])
+	msg.FatalIf("Error when retrieving uploaded version", err)
+	if c.source.GroupBy != "" && version.Placeholder == "" {
+		version, err = c.RetrieveGroupVersion(version)
+		msg.FatalIf("Error when retrieving uploaded group version", err)
+	}
+
+	json.NewEncoder(os.Stdout).Encode(model.Response{
+		Version:  version,
+		Metadata: metadata,
 	})
 }
 
+// RetrieveVersion gives version of an uploaded file in the same form as check does,
+// it is a placeholder on a dry run or for an exploded archive as file is not in artifactory.
+func (c Out) RetrieveVersion(uploadedFile UploadedFile) (model.Version, error) {
+	version := model.Version{
+		Path:   uploadedFile.Path,
+		Sha256: uploadedFile.Checksums.Sha256,
+	}
+	switch {
+	case c.params.DryRun:
+		// file has not been uploaded
+		version.Placeholder = model.PLACEHOLDER_DRY_RUN
+	case c.params.ExplodeArchive:
+		// artifactory only keeps files extracted from an exploded archive
+		version.Placeholder = model.PLACEHOLDER_NO_FILE
+	default:
+		info, err := utils.RetrieveStorageInfo(c.artdetails, c.source.CACert, uploadedFile.Path)
+		if err != nil {
+			return model.Version{}, err
+		}
+		version.Modified = info.LastModified
+	}
+	props, err := rtutils.ParseProperties(c.mergeProps())
+	if err != nil {
+		return model.Version{}, err
+	}
+	semverFound, err := utils.ExtractSemver(c.source.VersionStrategy, uploadedFile.Path, props.ToMap())
+	if err == nil {
+		version.Semver = semverFound.String()
+	}
+	return version, nil
+}
+
 func (c *Out) defaultingParams() {
 	if c.params.Threads <= 0 {
 		c.params.Threads = 3
 	}
+	if c.params.BuildName == "" && os.Getenv("BUILD_PIPELINE_NAME") != "" {
+		c.params.BuildName = os.Getenv("BUILD_PIPELINE_NAME") + "/" + os.Getenv("BUILD_JOB_NAME")
+	}
+	if c.params.BuildNumber == "" {
+		c.params.BuildNumber = os.Getenv("BUILD_NAME")
+	}
+	if c.params.IfExists == "" {
+		c.params.IfExists = IF_EXISTS_OVERWRITE
+	}
+}
+
+func (c Out) newSpec(src string, target string) *spec.SpecFiles {
+	builder := spec.NewBuilder()
+	return builder.
+		Pattern(src).
+		Target(target).
+		Props(c.mergeProps()).
+		Regexp(c.source.Regexp).
+		Recursive(true).
+		Flat(true).
+		BuildSpec()
 }
 
 func (c Out) folderPath(p string) string {
@@ -114,7 +302,53 @@ func (c Out) folderPath(p string) string {
 	return src
 }
 
-func (c Out) Upload() (int, int, error) {
+// Upload gives back files uploaded in artifactory sorted by their path, the first one is the primary artifact.
+// Files are first planned with a dry run, files already in artifactory are then skipped or deployed by checksum
+// and only remaining files are really uploaded.
+func (c Out) Upload() ([]UploadedFile, int, error) {
+	// exploded archives can't be deployed by checksum
+	if c.params.ExplodeArchive {
+		uploadedFiles, totalFailed, err := c.runUpload(c.spec, false)
+		return sortUploadedFiles(uploadedFiles), totalFailed, err
+	}
+	plannedFiles, totalFailed, err := c.runUpload(c.spec, true)
+	if err != nil || totalFailed > 0 {
+		return nil, totalFailed, err
+	}
+	plannedFiles, uploadedFiles, err := c.checkExistingTargets(plannedFiles)
+	if err != nil {
+		return nil, 0, err
+	}
+	// sync deletes keeps only files uploaded in the same upload, they can't be deployed by checksum one by one
+	if c.params.SyncDeletes != "" {
+		uploadedFiles, totalFailed, err = c.runUpload(c.spec, false)
+		return sortUploadedFiles(uploadedFiles), totalFailed, err
+	}
+	filesToUpload := make([]UploadedFile, 0)
+	for _, plannedFile := range plannedFiles {
+		transfer, err := c.DeployByChecksum(plannedFile)
+		if err != nil {
+			return nil, 0, err
+		}
+		if transfer == "" {
+			filesToUpload = append(filesToUpload, plannedFile)
+			continue
+		}
+		plannedFile.Transfer = transfer
+		uploadedFiles = append(uploadedFiles, plannedFile)
+	}
+	if len(filesToUpload) > 0 {
+		var transferredFiles []UploadedFile
+		transferredFiles, totalFailed, err = c.runUpload(c.filesSpec(filesToUpload), false)
+		if err != nil {
+			return nil, totalFailed, err
+		}
+		uploadedFiles = append(uploadedFiles, transferredFiles...)
+	}
+	return sortUploadedFiles(uploadedFiles), totalFailed, nil
+}
+
+func (c Out) runUpload(uploadSpec *spec.SpecFiles, dryRun bool) ([]UploadedFile, int, error) {
 	cmd := generic.NewUploadCommand()
 	cmd.SetUploadConfiguration(&artutils.UploadConfiguration{
 		Threads:        c.params.Threads,
@@ -122,10 +356,62 @@ func (c Out) Upload() (int, int, error) {
 	}).SetBuildConfiguration(&artutils.BuildConfiguration{})
 	cmd.
 		SetServerDetails(c.artdetails).
-		SetSpec(c.spec)
+		SetSpec(uploadSpec).
+		SetDetailedSummary(true).
+		SetDryRun(dryRun).
+		SetSyncDeletesPath(c.params.SyncDeletes)
 
 	err := cmd.Run()
-	return cmd.Result().SuccessCount(), cmd.Result().FailCount(), err
+	if err != nil {
+		return nil, cmd.Result().FailCount(), err
+	}
+	uploadedFiles, err := c.retrieveUploadedFiles(cmd)
+	return uploadedFiles, cmd.Result().FailCount(), err
+}
+
+func (c Out) retrieveUploadedFiles(cmd *generic.UploadCommand) ([]UploadedFile, error) {
+	uploadedFiles := make([]UploadedFile, 0)
+	reader := cmd.Result().Reader()
+	if reader == nil {
+		return uploadedFiles, nil
+	}
+	defer reader.Close()
+	for val := new(clientutils.FileTransferDetails); reader.NextRecord(val) == nil; val = new(clientutils.FileTransferDetails) {
+		uploadedFile, err := c.toUploadedFile(*val)
+		if err != nil {
+			return nil, err
+		}
+		uploadedFiles = append(uploadedFiles, uploadedFile)
+	}
+	if err := reader.GetError(); err != nil {
+		return nil, err
+	}
+	return uploadedFiles, nil
+}
+
+func sortUploadedFiles(uploadedFiles []UploadedFile) []UploadedFile {
+	sort.Slice(uploadedFiles, func(i, j int) bool {
+		return uploadedFiles[i].Path < uploadedFiles[j].Path
+	})
+	return uploadedFiles
+}
+
+func (c Out) toUploadedFile(transfer clientutils.FileTransferDetails) (UploadedFile, error) {
+	artPath, err := url.PathUnescape(strings.TrimPrefix(transfer.TargetPath, c.artdetails.ArtifactoryUrl))
+	if err != nil {
+		return UploadedFile{}, err
+	}
+	checksums, err := utils.FileChecksums(transfer.SourcePath)
+	if err != nil {
+		return UploadedFile{}, err
+	}
+	return UploadedFile{
+		Path:      artPath,
+		Checksums: checksums,
+		LocalPath: transfer.SourcePath,
+		Url:       transfer.TargetPath,
+		Transfer:  TRANSFER_UPLOADED,
+	}, nil
 }
 
 func (c Out) mergeProps() string {
@@ -145,6 +431,12 @@ func (c Out) mergeProps() string {
 		}
 		props += string(dat)
 	}
+	if c.params.PublishBuildInfo {
+		if props != "" {
+			props = strings.TrimSpace(props) + ";"
+		}
+		props += c.BuildProps()
+	}
 
 	return props
 }
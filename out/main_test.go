@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	"github.com/jfrog/jfrog-client-go/utils/io/fileutils"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+func newTestOut(t *testing.T, source model.Source, params model.OutParams) Out {
+	msg := &chelper.Messager{
+		LogWriter:      ioutil.Discard,
+		ResponseWriter: ioutil.Discard,
+		RequestReader:  strings.NewReader(`{"source": {}, "params": {}}`),
+		Directory:      t.TempDir(),
+	}
+	return Out{
+		cmd:    chelper.NewOutCommandWithMessager(msg),
+		source: source,
+		params: params,
+	}
+}
+
+// versions which are not in artifactory must be emitted without looking up artifactory (no server is set here)
+func TestRetrieveVersionWithoutFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      model.OutParams
+		placeholder string
+	}{
+		{
+			name:        "exploded archive",
+			params:      model.OutParams{ExplodeArchive: true},
+			placeholder: model.PLACEHOLDER_NO_FILE,
+		},
+		{
+			name:        "dry run",
+			params:      model.OutParams{DryRun: true},
+			placeholder: model.PLACEHOLDER_DRY_RUN,
+		},
+		{
+			name:        "dry run of an exploded archive",
+			params:      model.OutParams{DryRun: true, ExplodeArchive: true},
+			placeholder: model.PLACEHOLDER_DRY_RUN,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := newTestOut(t, model.Source{}, test.params)
+			version, err := out.RetrieveVersion(UploadedFile{
+				Path:      "repo/app/app-1.2.3.tgz",
+				Checksums: fileutils.ChecksumDetails{Sha256: "abc"},
+			})
+			if err != nil {
+				t.Fatalf("RetrieveVersion failed: %s", err)
+			}
+			expected := model.Version{
+				Path:        "repo/app/app-1.2.3.tgz",
+				Sha256:      "abc",
+				Semver:      "1.2.3",
+				Placeholder: test.placeholder,
+			}
+			if version != expected {
+				t.Errorf("RetrieveVersion gives %+v, expected %+v", version, expected)
+			}
+		})
+	}
+}
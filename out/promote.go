@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/jfrog/jfrog-client-go/artifactory/services"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// RunPromote promotes a build to a target repository, version emitted is the primary artifact of the build in target repository
+// (or a placeholder on a dry run or when build info doesn't give artifact paths).
+func (c Out) RunPromote() {
+	msg := c.cmd.Messager()
+	promote := c.params.Promote
+	if promote.BuildName == "" || promote.BuildNumber == "" || promote.TargetRepo == "" {
+		msg.Fatal("You must set build_name, build_number and target_repo in promote.")
+	}
+	if c.params.DryRun {
+		promote.DryRun = true
+	}
+	servicesManager, err := artutils.CreateServiceManager(c.artdetails, 0, promote.DryRun)
+	msg.FatalIf("Error when creating artifactory client", err)
+
+	buildInfoParams := services.NewBuildInfoParams()
+	buildInfoParams.BuildName = promote.BuildName
+	buildInfoParams.BuildNumber = promote.BuildNumber
+	publishedBuild, found, err := servicesManager.GetBuildInfo(buildInfoParams)
+	msg.FatalIf("Error when retrieving build info", err)
+	if !found {
+		msg.Fatal(fmt.Sprintf("Build '%s' number '%s' not found in artifactory", promote.BuildName, promote.BuildNumber))
+	}
+	artifactPaths := make([]string, 0)
+	totalPromoted := 0
+	for _, module := range publishedBuild.BuildInfo.Modules {
+		for _, artifact := range module.Artifacts {
+			// path is missing in build info published by old clients
+			if artifact.Path != "" {
+				artifactPaths = append(artifactPaths, artifact.Path)
+			}
+		}
+		totalPromoted += len(module.Artifacts)
+		if promote.IncludeDependencies {
+			totalPromoted += len(module.Dependencies)
+		}
+	}
+	if totalPromoted == 0 {
+		msg.Fatal(fmt.Sprintf("Build '%s' number '%s' has no artifact to promote", promote.BuildName, promote.BuildNumber))
+	}
+
+	promotionParams := services.NewPromotionParams()
+	promotionParams.BuildName = promote.BuildName
+	promotionParams.BuildNumber = promote.BuildNumber
+	promotionParams.SourceRepo = promote.SourceRepo
+	promotionParams.TargetRepo = promote.TargetRepo
+	promotionParams.Status = promote.Status
+	promotionParams.Comment = promote.Comment
+	promotionParams.Copy = promote.Copy
+	promotionParams.IncludeDependencies = promote.IncludeDependencies
+	promotionParams.FailFast = true
+
+	msg.Log("[blue]Promoting[reset] build '[blue]%s[reset]' number '[blue]%s[reset]' to '[blue]%s[reset]'...", promote.BuildName, promote.BuildNumber, promote.TargetRepo)
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	err = servicesManager.PromoteBuild(promotionParams)
+	os.Stdout = origStdout
+	msg.FatalIf("Error when promoting build", err)
+	msg.Log("[blue]Finished promoting[reset] build '[blue]%s[reset]' number '[blue]%s[reset]'.", promote.BuildName, promote.BuildNumber)
+
+	sort.Strings(artifactPaths)
+	operations := make([]PlannedOperation, 0)
+	for _, artifactPath := range artifactPaths {
+		operations = append(operations, PlannedOperation{
+			Operation: "promote",
+			Source:    artifactPath,
+			Target:    promotedPath(artifactPath, promote.TargetRepo),
+		})
+	}
+	if promote.DryRun {
+		c.logPlan(operations)
+	}
+
+	// nothing is in target repository on a dry run and build info of old clients doesn't give where artifacts are
+	version := model.Version{
+		Path:        promote.BuildName + "/" + promote.BuildNumber,
+		Placeholder: model.PLACEHOLDER_NO_FILE,
+	}
+	if len(artifactPaths) > 0 {
+		version = model.Version{
+			Path: promotedPath(artifactPaths[0], promote.TargetRepo),
+		}
+		semverFound, err := utils.ExtractSemver(c.source.VersionStrategy, version.Path, nil)
+		if err == nil {
+			version.Semver = semverFound.String()
+		}
+	}
+	if promote.DryRun {
+		version.Placeholder = model.PLACEHOLDER_DRY_RUN
+	}
+	metadata := []chelper.Metadata{
+		{
+			Name:  "promoted_build",
+			Value: promote.BuildName + "/" + promote.BuildNumber,
+		},
+		{
+			Name:  "target_repo",
+			Value: promote.TargetRepo,
+		},
+		{
+			Name:  "status",
+			Value: promote.Status,
+		},
+		{
+			Name:  "total_promoted",
+			Value: fmt.Sprintf("%d", totalPromoted),
+		},
+		{
+			Name:  "dry_run",
+			Value: fmt.Sprintf("%t", promote.DryRun),
+		},
+	}
+	if promote.DryRun {
+		metadata = append(metadata, planMetadata(operations)...)
+	}
+	json.NewEncoder(os.Stdout).Encode(model.Response{
+		Metadata: metadata,
+		Version:  version,
+	})
+}
+
+// promotedPath gives path of an artifact of build info (starting with its repository) in target repository.
+func promotedPath(artifactPath string, targetRepo string) string {
+	parts := strings.SplitN(artifactPath, "/", 2)
+	if len(parts) < 2 {
+		return targetRepo + "/" + artifactPath
+	}
+	return targetRepo + "/" + parts[1]
+}
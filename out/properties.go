@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	"github.com/jfrog/jfrog-cli-core/v2/artifactory/commands/generic"
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// RunProperties sets and deletes properties on the file of a prior get or on files found by a pattern,
+// properties are deleted before being set. Version emitted is the file of the prior get or the first file updated.
+func (c Out) RunProperties() {
+	msg := c.cmd.Messager()
+	params := *c.params.Properties
+	if (params.From == "") == (params.Pattern == "") {
+		msg.Fatal("You must set one of from (folder of a prior get) or pattern to set properties.")
+	}
+	if params.SetProps == "" && params.DeleteProps == "" {
+		msg.Fatal("You must set set_props and/or delete_props.")
+	}
+
+	pattern := params.Pattern
+	if params.From != "" {
+		// path file is written by in
+		fromFolder := utils.AddTrailingSlashIfNeeded(c.folderPath(params.From))
+		content, err := ioutil.ReadFile(fromFolder + "path")
+		msg.FatalIf(fmt.Sprintf("Error when reading artifactory path of file from '%s'", params.From), err)
+		pattern = strings.TrimSpace(string(content))
+	}
+	propsSpec := spec.NewBuilder().
+		Pattern(pattern).
+		Recursive(params.Recursive).
+		IncludeDirs(params.Recursive).
+		BuildSpec()
+
+	if c.params.DryRun {
+		c.runPropertiesDryRun(propsSpec, params, pattern)
+		return
+	}
+
+	origStdout := os.Stdout
+	totalDeleted, totalSet := 0, 0
+	if params.DeleteProps != "" {
+		msg.Logln("[blue]Deleting[reset] properties '[blue]%s[reset]' on '[blue]%s[reset]'...", params.DeleteProps, pattern)
+		os.Stdout = os.Stderr
+		var err error
+		totalDeleted, err = c.DeleteProps(propsSpec, params.DeleteProps)
+		os.Stdout = origStdout
+		msg.FatalIf("Error when deleting properties", err)
+	}
+	if params.SetProps != "" {
+		msg.Logln("[blue]Setting[reset] properties '[blue]%s[reset]' on '[blue]%s[reset]'...", params.SetProps, pattern)
+		os.Stdout = os.Stderr
+		var err error
+		totalSet, err = c.SetProps(propsSpec, params.SetProps)
+		os.Stdout = origStdout
+		msg.FatalIf("Error when setting properties", err)
+	}
+	if params.From != "" && totalDeleted == 0 && totalSet == 0 {
+		msg.Fatal(fmt.Sprintf("File '%s' not found in artifactory", pattern))
+	}
+	msg.Logln("[blue]Properties updated[reset]: %d item(s) set, %d item(s) deleted.", totalSet, totalDeleted)
+
+	items := make([]utils.SearchResult, 0)
+	if params.From == "" {
+		os.Stdout = os.Stderr
+		var err error
+		items, err = utils.Search(c.artdetails, propsSpec)
+		os.Stdout = origStdout
+		msg.FatalIf("Error when searching items updated", err)
+	}
+	version, err := c.propertiesVersion(params, pattern, items)
+	msg.FatalIf("Error when retrieving version", err)
+	json.NewEncoder(os.Stdout).Encode(model.Response{
+		Metadata: []chelper.Metadata{
+			{
+				Name:  "target",
+				Value: pattern,
+			},
+			{
+				Name:  "total_props_set",
+				Value: fmt.Sprintf("%d", totalSet),
+			},
+			{
+				Name:  "total_props_deleted",
+				Value: fmt.Sprintf("%d", totalDeleted),
+			},
+		},
+		Version: version,
+	})
+}
+
+// runPropertiesDryRun plans properties changes on items found with spec without changing them.
+func (c Out) runPropertiesDryRun(propsSpec *spec.SpecFiles, params model.PropertiesParams, pattern string) {
+	msg := c.cmd.Messager()
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	items, err := utils.Search(c.artdetails, propsSpec)
+	os.Stdout = origStdout
+	msg.FatalIf("Error when searching items to update", err)
+	if params.From != "" && len(items) == 0 {
+		msg.Fatal(fmt.Sprintf("File '%s' not found in artifactory", pattern))
+	}
+	operations := make([]PlannedOperation, 0)
+	for _, item := range items {
+		if params.DeleteProps != "" {
+			operations = append(operations, PlannedOperation{
+				Operation: "delete_props",
+				Source:    item.Path,
+				Props:     params.DeleteProps,
+			})
+		}
+		if params.SetProps != "" {
+			operations = append(operations, PlannedOperation{
+				Operation: "set_props",
+				Source:    item.Path,
+				Props:     params.SetProps,
+			})
+		}
+	}
+	c.logPlan(operations)
+
+	version, err := c.propertiesVersion(params, pattern, items)
+	msg.FatalIf("Error when retrieving version", err)
+	json.NewEncoder(os.Stdout).Encode(model.Response{
+		Metadata: append(dryRunMetadata(), planMetadata(operations)...),
+		Version:  version,
+	})
+}
+
+// propertiesVersion gives the version of the file of the prior get or of the first file updated in alphabetical order,
+// it is a placeholder when only folders are updated.
+func (c Out) propertiesVersion(params model.PropertiesParams, pattern string, items []utils.SearchResult) (model.Version, error) {
+	if params.From != "" {
+		return c.VersionFromArtifactory(pattern)
+	}
+	firstFile := ""
+	for _, item := range items {
+		if item.Type == "folder" {
+			continue
+		}
+		if firstFile == "" || item.Path < firstFile {
+			firstFile = item.Path
+		}
+	}
+	if firstFile == "" {
+		return model.Version{
+			Path:        pattern,
+			Placeholder: model.PLACEHOLDER_NO_FILE,
+		}, nil
+	}
+	return c.VersionFromArtifactory(firstFile)
+}
+
+// SetProps sets properties on files found with spec, it gives the number of files updated.
+func (c Out) SetProps(propsSpec *spec.SpecFiles, props string) (int, error) {
+	propsCmd := generic.NewPropsCommand()
+	propsCmd.SetProps(props).SetThreads(c.params.Threads)
+	propsCmd.SetServerDetails(c.artdetails).SetSpec(propsSpec)
+	cmd := generic.NewSetPropsCommand().SetPropsCommand(*propsCmd)
+	err := cmd.Run()
+	return cmd.Result().SuccessCount(), err
+}
+
+// DeleteProps deletes properties, given by their keys, on files found with spec, it gives the number of files updated.
+func (c Out) DeleteProps(propsSpec *spec.SpecFiles, keys string) (int, error) {
+	propsCmd := generic.NewPropsCommand()
+	propsCmd.SetProps(keys).SetThreads(c.params.Threads)
+	propsCmd.SetServerDetails(c.artdetails).SetSpec(propsSpec)
+	cmd := generic.NewDeletePropsCommand().DeletePropsCommand(*propsCmd)
+	err := cmd.Run()
+	return cmd.Result().SuccessCount(), err
+}
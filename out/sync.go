@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// PlanSyncDeletes gives files under sync_deletes path which are not targets of files to upload,
+// they are the ones deleted by upload. Files are always logged and nothing is uploaded
+// when there are more than max_deletions.
+func (c Out) PlanSyncDeletes() ([]utils.SearchResult, error) {
+	msg := c.cmd.Messager()
+	maxDeletions := c.params.MaxDeletions
+	if maxDeletions <= 0 {
+		maxDeletions = DEFAULT_MAX_DELETIONS
+	}
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() {
+		os.Stdout = origStdout
+	}()
+	plannedFiles, _, err := c.runUpload(c.spec, true)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]bool)
+	for _, plannedFile := range plannedFiles {
+		targets[plannedFile.Path] = true
+	}
+	// same search as the one done by upload for deleting files
+	searchSpec := spec.NewBuilder().
+		Pattern(c.params.SyncDeletes).
+		Recursive(true).
+		BuildSpec()
+	remoteFiles, err := utils.Search(c.artdetails, searchSpec)
+	if err != nil {
+		return nil, err
+	}
+	staleFiles := make([]utils.SearchResult, 0)
+	for _, remoteFile := range remoteFiles {
+		if !targets[remoteFile.Path] {
+			staleFiles = append(staleFiles, remoteFile)
+		}
+	}
+
+	os.Stdout = origStdout
+	msg.Logln("[blue]Sync deletes preview[reset]: %d file(s) in '[blue]%s[reset]' not in source will be deleted", len(staleFiles), c.params.SyncDeletes)
+	// a dry run logs them in its plan
+	if !c.params.DryRun {
+		for _, staleFile := range staleFiles {
+			msg.Logln("  - [red]%s[reset]", staleFile.Path)
+		}
+	}
+	if len(staleFiles) > maxDeletions {
+		return nil, fmt.Errorf("Sync would delete %d files which is more than max_deletions (%d), nothing has been uploaded.", len(staleFiles), maxDeletions)
+	}
+	return staleFiles, nil
+}
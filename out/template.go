@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+
+	rtutils "github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// BUILD_VARS are concourse build env vars given to templates by their key in template (e.g.: {{.BuildID}}).
+var BUILD_VARS = map[string]string{
+	"BuildID":           "BUILD_ID",
+	"BuildName":         "BUILD_NAME",
+	"BuildJobName":      "BUILD_JOB_NAME",
+	"BuildPipelineName": "BUILD_PIPELINE_NAME",
+	"BuildTeamName":     "BUILD_TEAM_NAME",
+	"AtcExternalUrl":    "ATC_EXTERNAL_URL",
+}
+
+// RenderTarget gives target where files found in src are uploaded, target and filename params are rendered as templates
+// with version and build vars, it fails on any template error before anything is uploaded.
+func (c Out) RenderTarget(src string) (string, error) {
+	target := utils.AddTrailingSlashIfNeeded(c.params.Target)
+	if !strings.Contains(target, "{{") && c.params.Filename == "" {
+		return target, nil
+	}
+	var sourceFiles []UploadedFile
+	if c.params.Filename != "" || c.params.VersionFile == "" {
+		plannedFiles, _, err := c.runUpload(c.newSpec(src, target), true)
+		if err != nil {
+			return "", err
+		}
+		sourceFiles = sortUploadedFiles(plannedFiles)
+	}
+	data, err := c.templateData(sourceFiles)
+	if err != nil {
+		return "", err
+	}
+	target, err = renderTemplate("target", target, data)
+	if err != nil {
+		return "", err
+	}
+	if c.params.Filename == "" {
+		return target, nil
+	}
+	if len(sourceFiles) != 1 {
+		return "", fmt.Errorf("You can set filename only when source matches a single file but %d files found.", len(sourceFiles))
+	}
+	filename, err := renderTemplate("filename", c.params.Filename, data)
+	if err != nil {
+		return "", err
+	}
+	return target + filename, nil
+}
+
+// templateData gives build vars set in env and version, read from version_file or found following version_strategy of source
+// (falling back to file name) in the path of the first source file (or of the first file put in archive when source is archived).
+// Keys missing in data make rendering fail.
+func (c Out) templateData(sourceFiles []UploadedFile) (map[string]string, error) {
+	data := make(map[string]string)
+	for key, envVar := range BUILD_VARS {
+		if value := os.Getenv(envVar); value != "" {
+			data[key] = value
+		}
+	}
+	if c.params.VersionFile != "" {
+		content, err := ioutil.ReadFile(c.folderPath(c.params.VersionFile))
+		if err != nil {
+			return nil, fmt.Errorf("Error when reading version file: %s", err.Error())
+		}
+		data["Version"] = strings.TrimSpace(string(content))
+		return data, nil
+	}
+	versionPath := c.packedSource
+	if versionPath == "" && len(sourceFiles) > 0 {
+		versionPath = sourceFiles[0].LocalPath
+	}
+	if versionPath == "" {
+		return data, nil
+	}
+	// as check does, props are the ones set on uploaded files
+	props, err := rtutils.ParseProperties(c.mergeProps())
+	if err != nil {
+		return nil, err
+	}
+	semverFound, err := utils.ExtractSemver(c.source.VersionStrategy, versionPath, props.ToMap())
+	if err != nil {
+		semverFound, err = utils.SemverFromPath(versionPath)
+	}
+	if err == nil {
+		data["Version"] = semverFound.String()
+	}
+	return data, nil
+}
+
+func renderTemplate(name string, text string, data map[string]string) (string, error) {
+	tpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("Cannot parse %s template: %s", name, err.Error())
+	}
+	buf := &bytes.Buffer{}
+	err = tpl.Execute(buf, data)
+	if err != nil {
+		return "", fmt.Errorf("Cannot render %s template: %s", name, err.Error())
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+func TestTemplateDataVersion(t *testing.T) {
+	segment := -2
+	tests := []struct {
+		name      string
+		localPath string
+		strategy  model.VersionStrategy
+		props     string
+		expected  string
+	}{
+		{
+			name:      "file name",
+			localPath: "/tmp/build/put/app-1.2.3.tgz",
+			expected:  "1.2.3",
+		},
+		{
+			name:      "regex",
+			localPath: "/tmp/build/put/app-linux-2.0-build5.tgz",
+			strategy:  model.VersionStrategy{Regex: `build(?P<version>[0-9]+)\.tgz$`},
+			expected:  "5.0.0",
+		},
+		{
+			name:      "path segment",
+			localPath: "/tmp/build/put/3.1/app.tgz",
+			strategy:  model.VersionStrategy{PathSegment: &segment},
+			expected:  "3.1.0",
+		},
+		{
+			name:      "property",
+			localPath: "/tmp/build/put/app.tgz",
+			strategy:  model.VersionStrategy{Property: "app.version"},
+			props:     "app.version=4.5.6;team=core",
+			expected:  "4.5.6",
+		},
+		{
+			name:      "strategy finding nothing falls back to file name",
+			localPath: "/tmp/build/put/app-1.2.3.tgz",
+			strategy:  model.VersionStrategy{Property: "app.version"},
+			expected:  "1.2.3",
+		},
+		{
+			name:      "no version",
+			localPath: "/tmp/build/put/app.tgz",
+			expected:  "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := newTestOut(t, model.Source{VersionStrategy: test.strategy}, model.OutParams{Props: test.props})
+			data, err := out.templateData([]UploadedFile{{LocalPath: test.localPath}})
+			if err != nil {
+				t.Fatalf("templateData failed: %s", err)
+			}
+			if data["Version"] != test.expected {
+				t.Errorf("Version is %q, expected %q", data["Version"], test.expected)
+			}
+		})
+	}
+}
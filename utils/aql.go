@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+// AQL_INCLUDE_FIELDS are fields needed to create versions from aql items.
+var AQL_INCLUDE_FIELDS = []string{"name", "repo", "path", "actual_md5", "actual_sha1", "sha256", "size", "type", "modified", "created"}
+
+func CheckAqlQuery(source model.Source) error {
+	if len(source.Query.Find) == 0 {
+		return errors.New("You must provide criteria in items.find of your query (e.g.: {\"repo\": \"local_generic\"}).")
+	}
+	if source.Query.Limit < 0 {
+		return errors.New("Limit of your query can't be negative.")
+	}
+	if !AqlQueryIncludesProps(*source.Query) && source.VersionStrategy.Property != "" {
+		return errors.New("Properties can't be retrieved in a sorted or limited query, you can't use sort or limit in query with property in version_strategy.")
+	}
+	return CheckReqParams(source)
+}
+
+// BuildAqlQuery converts query to an aql string,
+// properties are included only when query is not sorted nor limited as artifactory doesn't allow it.
+func BuildAqlQuery(query model.AqlQuery) (string, error) {
+	find, err := json.Marshal(query.Find)
+	if err != nil {
+		return "", err
+	}
+	include := append([]string{}, AQL_INCLUDE_FIELDS...)
+	if AqlQueryIncludesProps(query) {
+		include = append(include, "property")
+	}
+	aql := fmt.Sprintf(`items.find(%s).include("%s")`, find, strings.Join(include, `","`))
+	if len(query.Sort) > 0 {
+		sort, err := json.Marshal(query.Sort)
+		if err != nil {
+			return "", err
+		}
+		aql += fmt.Sprintf(".sort(%s)", sort)
+	}
+	if query.Limit > 0 {
+		aql += fmt.Sprintf(".limit(%d)", query.Limit)
+	}
+	return aql, nil
+}
+
+// AqlQueryIncludesProps tells if properties of items are given by query, artifactory doesn't give them in a sorted or limited query.
+func AqlQueryIncludesProps(query model.AqlQuery) bool {
+	return len(query.Sort) == 0 && query.Limit <= 0
+}
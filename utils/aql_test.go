@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+func TestBuildAqlQuery(t *testing.T) {
+	fields := `"name","repo","path","actual_md5","actual_sha1","sha256","size","type","modified","created"`
+	tests := []struct {
+		name     string
+		query    model.AqlQuery
+		expected string
+	}{
+		{
+			name:     "find only",
+			query:    model.AqlQuery{Find: map[string]interface{}{"repo": "libs"}},
+			expected: `items.find({"repo":"libs"}).include(` + fields + `,"property")`,
+		},
+		{
+			name: "sorted",
+			query: model.AqlQuery{
+				Find: map[string]interface{}{"repo": "libs"},
+				Sort: map[string][]string{"$desc": {"created"}},
+			},
+			expected: `items.find({"repo":"libs"}).include(` + fields + `).sort({"$desc":["created"]})`,
+		},
+		{
+			name: "limited",
+			query: model.AqlQuery{
+				Find:  map[string]interface{}{"repo": "libs"},
+				Limit: 10,
+			},
+			expected: `items.find({"repo":"libs"}).include(` + fields + `).limit(10)`,
+		},
+		{
+			name: "sorted and limited",
+			query: model.AqlQuery{
+				Find:  map[string]interface{}{"name": map[string]interface{}{"$match": "*.tgz"}},
+				Sort:  map[string][]string{"$asc": {"name"}},
+				Limit: 1,
+			},
+			expected: `items.find({"name":{"$match":"*.tgz"}}).include(` + fields + `).sort({"$asc":["name"]}).limit(1)`,
+		},
+	}
+	for _, test := range tests {
+		aql, err := BuildAqlQuery(test.query)
+		if err != nil {
+			t.Errorf("%s: BuildAqlQuery failed: %s", test.name, err)
+			continue
+		}
+		if aql != test.expected {
+			t.Errorf("%s: BuildAqlQuery gives\n%s\nexpected\n%s", test.name, aql, test.expected)
+		}
+	}
+}
+
+func TestCheckAqlQuery(t *testing.T) {
+	find := map[string]interface{}{"repo": "libs"}
+	tests := []struct {
+		name     string
+		query    model.AqlQuery
+		strategy model.VersionStrategy
+		wantErr  bool
+	}{
+		{name: "valid", query: model.AqlQuery{Find: find, Limit: 5}},
+		{name: "no criteria", query: model.AqlQuery{}, wantErr: true},
+		{name: "negative limit", query: model.AqlQuery{Find: find, Limit: -1}, wantErr: true},
+		{
+			name:     "property strategy",
+			query:    model.AqlQuery{Find: find},
+			strategy: model.VersionStrategy{Property: "version"},
+		},
+		{
+			name:     "property strategy with sort",
+			query:    model.AqlQuery{Find: find, Sort: map[string][]string{"$desc": {"created"}}},
+			strategy: model.VersionStrategy{Property: "version"},
+			wantErr:  true,
+		},
+		{
+			name:     "property strategy with limit",
+			query:    model.AqlQuery{Find: find, Limit: 5},
+			strategy: model.VersionStrategy{Property: "version"},
+			wantErr:  true,
+		},
+	}
+	for _, test := range tests {
+		query := test.query
+		source := model.Source{
+			Url:             "https://artifactory.example.com",
+			User:            "user",
+			Password:        "password",
+			Query:           &query,
+			VersionStrategy: test.strategy,
+		}
+		err := CheckAqlQuery(source)
+		if test.wantErr && err == nil {
+			t.Errorf("%s: CheckAqlQuery should fail", test.name)
+		}
+		if !test.wantErr && err != nil {
+			t.Errorf("%s: CheckAqlQuery failed: %s", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+
+	"github.com/jfrog/jfrog-client-go/utils/io/fileutils"
+)
+
+// FileChecksums gives md5, sha1 and sha256 of a local file, jfrog client doesn't compute sha256.
+func FileChecksums(filePath string) (fileutils.ChecksumDetails, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fileutils.ChecksumDetails{}, err
+	}
+	defer file.Close()
+	md5Hash, sha1Hash, sha256Hash := md5.New(), sha1.New(), sha256.New()
+	_, err = io.Copy(io.MultiWriter(md5Hash, sha1Hash, sha256Hash), file)
+	if err != nil {
+		return fileutils.ChecksumDetails{}, err
+	}
+	return fileutils.ChecksumDetails{
+		Md5:    hex.EncodeToString(md5Hash.Sum(nil)),
+		Sha1:   hex.EncodeToString(sha1Hash.Sum(nil)),
+		Sha256: hex.EncodeToString(sha256Hash.Sum(nil)),
+	}, nil
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+const (
+	GROUP_BY_VERSION = "version"
+	GROUP_BY_FOLDER  = "folder"
+)
+
+// GroupResults collapses results sharing the same version or the same folder in one result per group,
+// results without semver are skipped (and logged) when grouping by version.
+func GroupResults(msg *chelper.Messager, results []SearchResult, groupBy string, strategy model.VersionStrategy) ([]SearchResult, error) {
+	groups := make(map[string][]SearchResult)
+	groupKeys := make([]string, 0)
+	for _, file := range results {
+		var key string
+		switch groupBy {
+		case GROUP_BY_VERSION:
+			semverFound, err := ExtractSemver(strategy, file.Path, file.Props)
+			if err != nil {
+				msg.Logln("[yellow]Error[reset] for file '[blue]%s[reset]': %s [reset]", file.Path, err.Error())
+				continue
+			}
+			key = semverFound.String()
+		case GROUP_BY_FOLDER:
+			key = path.Dir(file.Path)
+		default:
+			return nil, fmt.Errorf("Unknown group_by '%s', valid values are: %s, %s", groupBy, GROUP_BY_VERSION, GROUP_BY_FOLDER)
+		}
+		if _, ok := groups[key]; !ok {
+			groupKeys = append(groupKeys, key)
+		}
+		groups[key] = append(groups[key], file)
+	}
+	grouped := make([]SearchResult, 0)
+	for _, key := range groupKeys {
+		grouped = append(grouped, MergeGroup(groups[key]))
+	}
+	return grouped, nil
+}
+
+// MergeGroup gives a single result for files of a group.
+// Primary file of a group is the one with the shortest path (e.g.: app-1.2.3.tgz in front of app-1.2.3.tgz.sha256),
+// dates are the latest ones of the group and sha256 is the one of GroupSha256.
+func MergeGroup(files []SearchResult) SearchResult {
+	sort.Slice(files, func(i, j int) bool {
+		if len(files[i].Path) != len(files[j].Path) {
+			return len(files[i].Path) < len(files[j].Path)
+		}
+		return files[i].Path < files[j].Path
+	})
+	group := files[0]
+	group.Files = make([]string, 0)
+	sortedPaths := make([]SearchResult, len(files))
+	copy(sortedPaths, files)
+	sort.Slice(sortedPaths, func(i, j int) bool {
+		return sortedPaths[i].Path < sortedPaths[j].Path
+	})
+	for _, file := range sortedPaths {
+		group.Files = append(group.Files, file.Path)
+		if DateLess(group.Created, file.Created) {
+			group.Created = file.Created
+		}
+		if DateLess(group.Modified, file.Modified) {
+			group.Modified = file.Modified
+		}
+	}
+	group.Sha256 = GroupSha256(files)
+	return group
+}
+
+// GroupSha256 computes sha256 of a group from path and sha256 (or sha1 when unknown) of each file,
+// it changes when a file of the group changes.
+func GroupSha256(files []SearchResult) string {
+	sortedPaths := make([]SearchResult, len(files))
+	copy(sortedPaths, files)
+	sort.Slice(sortedPaths, func(i, j int) bool {
+		return sortedPaths[i].Path < sortedPaths[j].Path
+	})
+	hash := sha256.New()
+	for _, file := range sortedPaths {
+		checksum := file.Sha256
+		if checksum == "" {
+			checksum = file.Sha1
+		}
+		fmt.Fprintf(hash, "%s:%s\n", file.Path, checksum)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// ResultToVersion gives the version of a result as check gives it.
+func ResultToVersion(file SearchResult, strategy model.VersionStrategy) model.Version {
+	version := model.Version{
+		Path:     file.Path,
+		Sha256:   file.Sha256,
+		Modified: file.Modified,
+		Files:    strings.Join(file.Files, ","),
+	}
+	semverFound, err := ExtractSemver(strategy, file.Path, file.Props)
+	if err == nil {
+		version.Semver = semverFound.String()
+	}
+	return version
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+func TestGroupResults(t *testing.T) {
+	results := []SearchResult{
+		searchResult("repo/app/1.2.3/app-1.2.3.tgz.sha256", "2021-01-01T00:00:00.000Z", "2021-01-02T00:00:00.000Z"),
+		searchResult("repo/app/1.2.3/app-1.2.3.tgz", "2021-01-01T00:00:00.000Z", "2021-01-01T00:00:00.000Z"),
+		searchResult("repo/app/1.3.0/app-1.3.0.tgz", "2021-01-03T00:00:00.000Z", "2021-01-03T00:00:00.000Z"),
+		searchResult("repo/app/1.3.0/README", "2021-01-03T00:00:00.000Z", "2021-01-03T00:00:00.000Z"),
+	}
+	tests := []struct {
+		groupBy  string
+		expected []SearchResult
+		wantErr  bool
+	}{
+		{
+			groupBy: GROUP_BY_VERSION,
+			expected: []SearchResult{
+				{
+					SearchResult: searchResult("repo/app/1.2.3/app-1.2.3.tgz", "2021-01-01T00:00:00.000Z", "2021-01-02T00:00:00.000Z").SearchResult,
+					Files:        []string{"repo/app/1.2.3/app-1.2.3.tgz", "repo/app/1.2.3/app-1.2.3.tgz.sha256"},
+				},
+				{
+					SearchResult: searchResult("repo/app/1.3.0/app-1.3.0.tgz", "2021-01-03T00:00:00.000Z", "2021-01-03T00:00:00.000Z").SearchResult,
+					Files:        []string{"repo/app/1.3.0/app-1.3.0.tgz"},
+				},
+			},
+		},
+		{
+			groupBy: GROUP_BY_FOLDER,
+			expected: []SearchResult{
+				{
+					SearchResult: searchResult("repo/app/1.2.3/app-1.2.3.tgz", "2021-01-01T00:00:00.000Z", "2021-01-02T00:00:00.000Z").SearchResult,
+					Files:        []string{"repo/app/1.2.3/app-1.2.3.tgz", "repo/app/1.2.3/app-1.2.3.tgz.sha256"},
+				},
+				{
+					SearchResult: searchResult("repo/app/1.3.0/README", "2021-01-03T00:00:00.000Z", "2021-01-03T00:00:00.000Z").SearchResult,
+					Files:        []string{"repo/app/1.3.0/README", "repo/app/1.3.0/app-1.3.0.tgz"},
+				},
+			},
+		},
+		{
+			groupBy: "name",
+			wantErr: true,
+		},
+	}
+	msg := &chelper.Messager{LogWriter: ioutil.Discard}
+	for _, test := range tests {
+		input := append([]SearchResult{}, results...)
+		grouped, err := GroupResults(msg, input, test.groupBy, model.VersionStrategy{})
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("GroupResults by %q should fail", test.groupBy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("GroupResults by %q failed: %s", test.groupBy, err)
+			continue
+		}
+		for i := range grouped {
+			// sha256 of groups is tested apart
+			grouped[i].Sha256 = ""
+		}
+		if !reflect.DeepEqual(grouped, test.expected) {
+			t.Errorf("GroupResults by %q gives %+v, expected %+v", test.groupBy, grouped, test.expected)
+		}
+	}
+}
+
+func TestGroupSha256(t *testing.T) {
+	file := func(path, sha256, sha1 string) SearchResult {
+		result := searchResult(path, "", "")
+		result.Sha256 = sha256
+		result.Sha1 = sha1
+		return result
+	}
+	group := []SearchResult{
+		file("repo/app-1.2.3.tgz", "a", "1"),
+		file("repo/app-1.2.3.tgz.asc", "", "2"),
+	}
+	sha := GroupSha256(group)
+	tests := []struct {
+		name  string
+		files []SearchResult
+		same  bool
+	}{
+		{
+			name:  "same files in another order",
+			files: []SearchResult{group[1], group[0]},
+			same:  true,
+		},
+		{
+			name:  "file content changed",
+			files: []SearchResult{file("repo/app-1.2.3.tgz", "b", "1"), group[1]},
+		},
+		{
+			name:  "file without sha256 changed",
+			files: []SearchResult{group[0], file("repo/app-1.2.3.tgz.asc", "", "3")},
+		},
+		{
+			name:  "file added",
+			files: append([]SearchResult{file("repo/app-1.2.3.sbom.json", "c", "4")}, group...),
+		},
+		{
+			name:  "file removed",
+			files: group[:1],
+		},
+	}
+	for _, test := range tests {
+		if same := GroupSha256(test.files) == sha; same != test.same {
+			t.Errorf("%s: same sha256 is %t, expected %t", test.name, same, test.same)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	"github.com/blang/semver"
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
+	rtutils "github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+const (
+	ORDER_BY_CREATED  = "created"
+	ORDER_BY_MODIFIED = "modified"
+	ORDER_BY_PATH     = "path"
+	ORDER_BY_SEMVER   = "semver"
+)
+
+// SearchResult is a jfrog search result with the sha256 of the file which is not kept by jfrog cli search command.
+// Files are set when result is a group of files, path is then the path of the primary file of the group.
+type SearchResult struct {
+	artutils.SearchResult
+	Sha256 string
+	Files  []string
+}
+
+// Search runs search from jfrog client directly instead of jfrog cli search command for keeping sha256 of files.
+func Search(artdetails *config.ServerDetails, searchSpec *spec.SpecFiles) ([]SearchResult, error) {
+	res := []SearchResult{}
+	servicesManager, err := artutils.CreateServiceManager(artdetails, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	searchParams, err := artutils.GetSearchParams(searchSpec.Get(0))
+	if err != nil {
+		return nil, err
+	}
+	reader, err := servicesManager.SearchFiles(searchParams)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	for val := new(rtutils.ResultItem); reader.NextRecord(val) == nil; val = new(rtutils.ResultItem) {
+		res = append(res, toSearchResult(*val))
+	}
+
+	return res, reader.GetError()
+}
+
+// SearchAql runs an aql query and gives files found.
+func SearchAql(artdetails *config.ServerDetails, aqlQuery model.AqlQuery) ([]SearchResult, error) {
+	res := []SearchResult{}
+	servicesManager, err := artutils.CreateServiceManager(artdetails, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	query, err := BuildAqlQuery(aqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	body, err := servicesManager.Aql(query)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var aqlResults struct {
+		Results []rtutils.ResultItem `json:"results"`
+	}
+	err = json.NewDecoder(body).Decode(&aqlResults)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range aqlResults.Results {
+		res = append(res, toSearchResult(item))
+	}
+	return res, nil
+}
+
+func toSearchResult(item rtutils.ResultItem) SearchResult {
+	props := make(map[string][]string, len(item.Properties))
+	for _, prop := range item.Properties {
+		props[prop.Key] = append(props[prop.Key], prop.Value)
+	}
+	return SearchResult{
+		SearchResult: artutils.SearchResult{
+			Path:     item.GetItemRelativePath(),
+			Type:     item.Type,
+			Size:     item.Size,
+			Created:  item.Created,
+			Modified: item.Modified,
+			Sha1:     item.Actual_Sha1,
+			Md5:      item.Actual_Md5,
+			Props:    props,
+		},
+		Sha256: item.Sha256,
+	}
+}
+
+// SortResults sorts results from oldest to newest on order_by key,
+// results without semver are skipped (and logged) when ordering by semver.
+func SortResults(msg *chelper.Messager, results []SearchResult, orderBy string, strategy model.VersionStrategy) ([]SearchResult, error) {
+	sortedResults := make([]SearchResult, 0)
+	var less func(i, j int) bool
+	switch orderBy {
+	case ORDER_BY_CREATED:
+		sortedResults = append(sortedResults, results...)
+		less = func(i, j int) bool {
+			return DateLess(sortedResults[i].Created, sortedResults[j].Created)
+		}
+	case ORDER_BY_MODIFIED:
+		sortedResults = append(sortedResults, results...)
+		less = func(i, j int) bool {
+			return DateLess(sortedResults[i].Modified, sortedResults[j].Modified)
+		}
+	case ORDER_BY_PATH:
+		sortedResults = append(sortedResults, results...)
+		less = func(i, j int) bool {
+			return sortedResults[i].Path < sortedResults[j].Path
+		}
+	case ORDER_BY_SEMVER:
+		semvers := make(map[string]semver.Version)
+		for _, file := range results {
+			semverFound, err := ExtractSemver(strategy, file.Path, file.Props)
+			if err != nil {
+				msg.Logln("[yellow]Error[reset] for file '[blue]%s[reset]': %s [reset]", file.Path, err.Error())
+				continue
+			}
+			semvers[file.Path] = semverFound
+			sortedResults = append(sortedResults, file)
+		}
+		less = func(i, j int) bool {
+			return SemverLT(semvers[sortedResults[i].Path], semvers[sortedResults[j].Path])
+		}
+	default:
+		return nil, fmt.Errorf("Unknown order_by '%s', valid values are: %s, %s, %s, %s",
+			orderBy, ORDER_BY_CREATED, ORDER_BY_MODIFIED, ORDER_BY_PATH, ORDER_BY_SEMVER)
+	}
+	// sorting by path first make order stable when keys are equal
+	sort.SliceStable(sortedResults, func(i, j int) bool {
+		return sortedResults[i].Path < sortedResults[j].Path
+	})
+	sort.SliceStable(sortedResults, less)
+	return sortedResults, nil
+}
+
+// DateLess compares artifactory dates, dates which can't be parsed are compared as strings.
+func DateLess(date1, date2 string) bool {
+	time1, err1 := time.Parse(time.RFC3339, date1)
+	time2, err2 := time.Parse(time.RFC3339, date2)
+	if err1 != nil || err2 != nil {
+		return date1 < date2
+	}
+	return time1.Before(time2)
+}
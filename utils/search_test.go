@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	chelper "github.com/ArthurHlt/go-concourse-helper"
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+func searchResult(path, created, modified string) SearchResult {
+	return SearchResult{
+		SearchResult: artutils.SearchResult{
+			Path:     path,
+			Created:  created,
+			Modified: modified,
+		},
+	}
+}
+
+func resultPaths(results []SearchResult) []string {
+	paths := make([]string, 0)
+	for _, result := range results {
+		paths = append(paths, result.Path)
+	}
+	return paths
+}
+
+func TestSortResults(t *testing.T) {
+	results := []SearchResult{
+		searchResult("repo/app-1.10.0.tgz", "2021-01-03T00:00:00.000Z", "2021-01-04T00:00:00.000Z"),
+		searchResult("repo/app-1.2.0.tgz", "2021-01-01T00:00:00.000Z", "2021-01-05T00:00:00.000Z"),
+		searchResult("repo/app-latest.tgz", "2021-01-04T00:00:00.000Z", "2021-01-01T00:00:00.000Z"),
+		searchResult("repo/app-1.9.0.tgz", "2021-01-02T00:00:00.000Z", "2021-01-05T00:00:00.000Z"),
+	}
+	tests := []struct {
+		orderBy  string
+		strategy model.VersionStrategy
+		expected []string
+		wantErr  bool
+	}{
+		{
+			orderBy:  ORDER_BY_CREATED,
+			expected: []string{"repo/app-1.2.0.tgz", "repo/app-1.9.0.tgz", "repo/app-1.10.0.tgz", "repo/app-latest.tgz"},
+		},
+		{
+			// same modified date are ordered by path
+			orderBy:  ORDER_BY_MODIFIED,
+			expected: []string{"repo/app-latest.tgz", "repo/app-1.10.0.tgz", "repo/app-1.2.0.tgz", "repo/app-1.9.0.tgz"},
+		},
+		{
+			orderBy:  ORDER_BY_PATH,
+			expected: []string{"repo/app-1.10.0.tgz", "repo/app-1.2.0.tgz", "repo/app-1.9.0.tgz", "repo/app-latest.tgz"},
+		},
+		{
+			// file without semver is skipped
+			orderBy:  ORDER_BY_SEMVER,
+			expected: []string{"repo/app-1.2.0.tgz", "repo/app-1.9.0.tgz", "repo/app-1.10.0.tgz"},
+		},
+		{
+			orderBy:  ORDER_BY_SEMVER,
+			strategy: model.VersionStrategy{Regex: `app-(?P<version>1\.[0-9]\.0)`},
+			expected: []string{"repo/app-1.2.0.tgz", "repo/app-1.9.0.tgz"},
+		},
+		{
+			orderBy: "size",
+			wantErr: true,
+		},
+	}
+	msg := &chelper.Messager{LogWriter: ioutil.Discard}
+	for _, test := range tests {
+		sortedResults, err := SortResults(msg, results, test.orderBy, test.strategy)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("SortResults by %q should fail", test.orderBy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SortResults by %q failed: %s", test.orderBy, err)
+			continue
+		}
+		if paths := resultPaths(sortedResults); !reflect.DeepEqual(paths, test.expected) {
+			t.Errorf("SortResults by %q gives %v, expected %v", test.orderBy, paths, test.expected)
+		}
+	}
+}
+
+func TestDateLess(t *testing.T) {
+	tests := []struct {
+		date1    string
+		date2    string
+		expected bool
+	}{
+		{date1: "2021-01-01T00:00:00.000Z", date2: "2021-01-02T00:00:00.000Z", expected: true},
+		{date1: "2021-01-02T00:00:00.000Z", date2: "2021-01-01T00:00:00.000Z", expected: false},
+		{date1: "2021-01-01T02:00:00.000+02:00", date2: "2021-01-01T01:00:00.000Z", expected: true},
+		{date1: "2021-01-01T00:00:00.000Z", date2: "2021-01-01T00:00:00.000Z", expected: false},
+		{date1: "a", date2: "b", expected: true},
+	}
+	for _, test := range tests {
+		if less := DateLess(test.date1, test.date2); less != test.expected {
+			t.Errorf("DateLess(%q, %q) = %t, expected %t", test.date1, test.date2, less, test.expected)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+const (
+	VERSION_STRATEGY_GROUP = "version"
+	// SEMVER_REGEX finds a version candidate: numeric core (up to 4 components), qualifiers and build metadata,
+	// qualifiers are validated afterward as file names often have other words after the version (e.g.: app-1.2.3-linux.tgz).
+	SEMVER_REGEX = `(?:^|[^0-9a-z])v?(\d+(?:\.\d+){0,3})((?:[-.][0-9a-z]+)*)(\+[0-9a-z-]+(?:\.[0-9a-z-]+)*)?`
+)
+
+var (
+	semverRegex      = regexp.MustCompile("(?i)" + SEMVER_REGEX)
+	versionCoreRegex = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(.*)$`)
+	// a version in a range, it ends at next space, next range or next comparator
+	rangeVersionRegex = regexp.MustCompile(`v?\d[^\s|<>=!]*`)
+	wildcardRegex     = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(?:\.[xX*])+$`)
+	qualifierRegex    = regexp.MustCompile(`(?i)^(alpha|beta|rc|cr|dev|preview|pre|snapshot|milestone|final|ga|release)\d*$`)
+	numericRegex      = regexp.MustCompile(`^\d+$`)
+	// maven qualifiers meaning a release, they are not pre-releases
+	releaseQualifiers = []string{"final", "ga", "release"}
+	archiveExts       = []string{".tar"}
+)
+
+// SanitizeRange sanitizes each version of a semver range as SanitizeVersion does (e.g.: '>=1.2 <2' gives '>=1.2.0 <2.0.0'),
+// wildcards (e.g.: '1.x') are kept as is.
+func SanitizeRange(versionRange string) string {
+	return rangeVersionRegex.ReplaceAllStringFunc(versionRange, func(version string) string {
+		if match := wildcardRegex.FindStringSubmatch(version); match != nil {
+			return match[1] + ".x"
+		}
+		sanitized, err := SanitizeVersion(version)
+		if err != nil {
+			return version
+		}
+		return sanitized
+	})
+}
+
+// SanitizeVersion converts a version to semver 2.0:
+// missing components are padded, a 4th component is kept as build metadata
+// and maven qualifiers (e.g.: 1.2.3.RELEASE or 1.2.3.SNAPSHOT) are converted to pre-releases or removed for releases.
+func SanitizeVersion(version string) (string, error) {
+	match := versionCoreRegex.FindStringSubmatch(version)
+	if match == nil {
+		return "", fmt.Errorf("Version '%s' doesn't start with a number.", version)
+	}
+	core := strings.Split(match[1], ".")
+	if len(core) > 4 {
+		return "", fmt.Errorf("Version '%s' has more than 4 components.", version)
+	}
+	metadata := make([]string, 0)
+	if len(core) == 4 {
+		metadata = append(metadata, core[3])
+		core = core[:3]
+	}
+	for len(core) < 3 {
+		core = append(core, "0")
+	}
+	rest := match[2]
+	if strings.Contains(rest, "+") {
+		metadata = append(metadata, rest[strings.Index(rest, "+")+1:])
+		rest = rest[:strings.Index(rest, "+")]
+	}
+	pre := strings.TrimLeft(rest, "-.")
+	for _, releaseQualifier := range releaseQualifiers {
+		if strings.EqualFold(pre, releaseQualifier) {
+			pre = ""
+		}
+	}
+	sanitized := strings.Join(core, ".")
+	if pre != "" {
+		sanitized += "-" + pre
+	}
+	if len(metadata) > 0 {
+		sanitized += "+" + strings.Join(metadata, ".")
+	}
+	return sanitized, nil
+}
+
+// ParseVersion parses a version following semver 2.0 after sanitizing it.
+func ParseVersion(version string) (semver.Version, error) {
+	sanitized, err := SanitizeVersion(version)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	return semver.Make(sanitized)
+}
+
+// SemverLT is like semver LT but build metadata is compared when versions have the same precedence,
+// this allows ordering versions with 4 components (e.g.: 1.2.3.4 < 1.2.3.5).
+func SemverLT(v1, v2 semver.Version) bool {
+	if cmp := v1.Compare(v2); cmp != 0 {
+		return cmp < 0
+	}
+	for i := 0; i < len(v1.Build) && i < len(v2.Build); i++ {
+		if v1.Build[i] == v2.Build[i] {
+			continue
+		}
+		if numericRegex.MatchString(v1.Build[i]) && numericRegex.MatchString(v2.Build[i]) {
+			n1, _ := strconv.ParseUint(v1.Build[i], 10, 64)
+			n2, _ := strconv.ParseUint(v2.Build[i], 10, 64)
+			return n1 < n2
+		}
+		return v1.Build[i] < v2.Build[i]
+	}
+	return len(v1.Build) < len(v2.Build)
+}
+
+// SemverFromPath finds semver in the file name of an artifactory path.
+func SemverFromPath(path string) (semver.Version, error) {
+	pathSplitted := strings.Split(path, "/")
+	file := pathSplitted[len(pathSplitted)-1]
+	ext := filepath.Ext(file)
+	if ext != "" {
+		file = strings.TrimSuffix(file, ext)
+	}
+	for _, archiveExt := range archiveExts {
+		file = strings.TrimSuffix(file, archiveExt)
+	}
+	allMatch := semverRegex.FindAllStringSubmatch(file, -1)
+	if len(allMatch) == 0 {
+		return semver.Version{}, errors.New("Cannot find any semver in file.")
+	}
+	// prefer the last version with at least major and minor as file names can contain other numbers
+	match := allMatch[len(allMatch)-1]
+	for i := len(allMatch) - 1; i >= 0; i-- {
+		if strings.Contains(allMatch[i][1], ".") {
+			match = allMatch[i]
+			break
+		}
+	}
+	return ParseVersion(match[1] + filterQualifiers(match[2]) + match[3])
+}
+
+// filterQualifiers keeps qualifiers which looks like a pre-release (known qualifier or number)
+// and stops at the first other word.
+func filterQualifiers(qualifiers string) string {
+	kept := ""
+	for i, sep := 0, ""; len(qualifiers) > 0; i++ {
+		sep, qualifiers = qualifiers[:1], qualifiers[1:]
+		ident := qualifiers
+		if next := strings.IndexAny(qualifiers, "-."); next >= 0 {
+			ident = qualifiers[:next]
+		}
+		qualifiers = qualifiers[len(ident):]
+		isQualifier := qualifierRegex.MatchString(ident)
+		isNumeric := numericRegex.MatchString(ident)
+		// maven style qualifiers after a dot must be words, numbers after a dot are version components
+		if i == 0 && sep == "." && !isQualifier {
+			break
+		}
+		if !isQualifier && !isNumeric {
+			break
+		}
+		kept += sep + ident
+	}
+	return kept
+}
+
+func CheckVersionStrategy(strategy model.VersionStrategy) error {
+	nbStrategies := 0
+	if strategy.Regex != "" {
+		nbStrategies++
+		r, err := regexp.Compile(strategy.Regex)
+		if err != nil {
+			return fmt.Errorf("Invalid regex in version_strategy: %s", err.Error())
+		}
+		if r.SubexpIndex(VERSION_STRATEGY_GROUP) < 0 {
+			return fmt.Errorf("Regex in version_strategy must have a named capture group '%s' (e.g.: '(?P<%s>[0-9.]+)').", VERSION_STRATEGY_GROUP, VERSION_STRATEGY_GROUP)
+		}
+	}
+	if strategy.PathSegment != nil {
+		nbStrategies++
+	}
+	if strategy.Property != "" {
+		nbStrategies++
+	}
+	if nbStrategies > 1 {
+		return errors.New("You must set only one of regex, path_segment or property in version_strategy.")
+	}
+	return nil
+}
+
+// ExtractSemver finds semver of an artifactory file following version strategy.
+func ExtractSemver(strategy model.VersionStrategy, path string, props map[string][]string) (semver.Version, error) {
+	var versionFound string
+	switch {
+	case strategy.Regex != "":
+		r, err := regexp.Compile(strategy.Regex)
+		if err != nil {
+			return semver.Version{}, err
+		}
+		match := r.FindStringSubmatch(path)
+		groupIndex := r.SubexpIndex(VERSION_STRATEGY_GROUP)
+		if match == nil || groupIndex < 0 || match[groupIndex] == "" {
+			return semver.Version{}, errors.New("Cannot find any version with regex in path.")
+		}
+		versionFound = match[groupIndex]
+	case strategy.PathSegment != nil:
+		segments := strings.Split(path, "/")
+		index := *strategy.PathSegment
+		if index < 0 {
+			index += len(segments)
+		}
+		if index < 0 || index >= len(segments) {
+			return semver.Version{}, fmt.Errorf("Path has no segment at index %d.", *strategy.PathSegment)
+		}
+		versionFound = segments[index]
+	case strategy.Property != "":
+		values := props[strategy.Property]
+		if len(values) == 0 {
+			return semver.Version{}, fmt.Errorf("Cannot find property '%s' on file.", strategy.Property)
+		}
+		versionFound = values[0]
+	default:
+		return SemverFromPath(path)
+	}
+	return ParseVersion(versionFound)
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestSanitizeVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+		wantErr  bool
+	}{
+		{version: "1", expected: "1.0.0"},
+		{version: "1.2", expected: "1.2.0"},
+		{version: "1.2.3", expected: "1.2.3"},
+		{version: "v1.2.3", expected: "1.2.3"},
+		{version: "1.2.3.4", expected: "1.2.3+4"},
+		{version: "1.2.3.4+build", expected: "1.2.3+4.build"},
+		{version: "1.2-rc.1", expected: "1.2.0-rc.1"},
+		{version: "1.2.3.RELEASE", expected: "1.2.3"},
+		{version: "1.2.3.Final", expected: "1.2.3"},
+		{version: "1.2.3.SNAPSHOT", expected: "1.2.3-SNAPSHOT"},
+		{version: "1.2.3-beta+exp.sha", expected: "1.2.3-beta+exp.sha"},
+		{version: "1.2.3.4.5", wantErr: true},
+		{version: "latest", wantErr: true},
+	}
+	for _, test := range tests {
+		sanitized, err := SanitizeVersion(test.version)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("SanitizeVersion(%q) should fail, got %q", test.version, sanitized)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SanitizeVersion(%q) failed: %s", test.version, err)
+			continue
+		}
+		if sanitized != test.expected {
+			t.Errorf("SanitizeVersion(%q) = %q, expected %q", test.version, sanitized, test.expected)
+		}
+	}
+}
+
+func TestSanitizeRange(t *testing.T) {
+	tests := []struct {
+		versionRange string
+		expected     string
+		inRange      []string
+		outOfRange   []string
+	}{
+		{
+			versionRange: ">=1.2",
+			expected:     ">=1.2.0",
+			inRange:      []string{"1.2.0", "3.0.0"},
+			outOfRange:   []string{"1.1.9"},
+		},
+		{
+			versionRange: ">=1.2 <2.0",
+			expected:     ">=1.2.0 <2.0.0",
+			inRange:      []string{"1.2.0", "1.9.9"},
+			outOfRange:   []string{"1.1.0", "2.0.0"},
+		},
+		{
+			versionRange: ">=1 <2",
+			expected:     ">=1.0.0 <2.0.0",
+			inRange:      []string{"1.0.0", "1.5.0"},
+			outOfRange:   []string{"0.9.0", "2.0.0"},
+		},
+		{
+			versionRange: ">=1.2.0 <2.0",
+			expected:     ">=1.2.0 <2.0.0",
+			inRange:      []string{"1.2.0"},
+			outOfRange:   []string{"2.0.0"},
+		},
+		{
+			versionRange: "1.x",
+			expected:     "1.x",
+			inRange:      []string{"1.0.0", "1.9.0"},
+			outOfRange:   []string{"2.0.0"},
+		},
+		{
+			versionRange: ">=1.2.x",
+			expected:     ">=1.2.x",
+			inRange:      []string{"1.2.0", "1.3.0"},
+			outOfRange:   []string{"1.1.0"},
+		},
+		{
+			versionRange: "<1 || >=2.1",
+			expected:     "<1.0.0 || >=2.1.0",
+			inRange:      []string{"0.9.0", "2.1.0"},
+			outOfRange:   []string{"1.0.0", "2.0.0"},
+		},
+		{
+			versionRange: ">= 1.2 < 2",
+			expected:     ">= 1.2.0 < 2.0.0",
+			inRange:      []string{"1.2.0"},
+			outOfRange:   []string{"2.0.0"},
+		},
+		{
+			versionRange: ">=v1.2.3.4",
+			expected:     ">=1.2.3+4",
+			inRange:      []string{"1.2.3", "1.2.4"},
+			outOfRange:   []string{"1.2.2"},
+		},
+	}
+	for _, test := range tests {
+		sanitized := SanitizeRange(test.versionRange)
+		if sanitized != test.expected {
+			t.Errorf("SanitizeRange(%q) = %q, expected %q", test.versionRange, sanitized, test.expected)
+			continue
+		}
+		versionRange, err := semver.ParseRange(sanitized)
+		if err != nil {
+			t.Errorf("SanitizeRange(%q) gives %q which can't be parsed: %s", test.versionRange, sanitized, err)
+			continue
+		}
+		for _, version := range test.inRange {
+			if !versionRange(semver.MustParse(version)) {
+				t.Errorf("%s should be in range %q", version, test.versionRange)
+			}
+		}
+		for _, version := range test.outOfRange {
+			if versionRange(semver.MustParse(version)) {
+				t.Errorf("%s should not be in range %q", version, test.versionRange)
+			}
+		}
+	}
+}
+
+func TestSemverFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{path: "repo/app/app-1.2.3.zip", expected: "1.2.3"},
+		{path: "repo/app/app-1.2.3.tar.gz", expected: "1.2.3"},
+		{path: "repo/app/app-1.2.tgz", expected: "1.2.0"},
+		{path: "repo/app/app-1.2.3-rc.1.jar", expected: "1.2.3-rc.1"},
+		{path: "repo/app/app-1.2.3.RELEASE.jar", expected: "1.2.3"},
+		{path: "repo/app/app-1.2.3-SNAPSHOT.jar", expected: "1.2.3-SNAPSHOT"},
+		{path: "repo/app/app-1.2.3.4.jar", expected: "1.2.3+4"},
+		{path: "repo/app/app-1.2.3-linux-amd64.tgz", expected: "1.2.3"},
+		{path: "repo/app/app64-1.2.3.zip", expected: "1.2.3"},
+		{path: "repo/1.2.3/app.zip", wantErr: true},
+	}
+	for _, test := range tests {
+		version, err := SemverFromPath(test.path)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("SemverFromPath(%q) should fail, got %q", test.path, version)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SemverFromPath(%q) failed: %s", test.path, err)
+			continue
+		}
+		if version.String() != test.expected {
+			t.Errorf("SemverFromPath(%q) = %q, expected %q", test.path, version, test.expected)
+		}
+	}
+}
+
+func TestFilterQualifiers(t *testing.T) {
+	tests := []struct {
+		qualifiers string
+		expected   string
+	}{
+		{qualifiers: "", expected: ""},
+		{qualifiers: "-rc.1", expected: "-rc.1"},
+		{qualifiers: "-beta2", expected: "-beta2"},
+		{qualifiers: ".RELEASE", expected: ".RELEASE"},
+		{qualifiers: ".SNAPSHOT", expected: ".SNAPSHOT"},
+		{qualifiers: "-linux-amd64", expected: ""},
+		{qualifiers: "-rc.1-linux", expected: "-rc.1"},
+		{qualifiers: ".1", expected: ""},
+		{qualifiers: "-1", expected: "-1"},
+	}
+	for _, test := range tests {
+		if kept := filterQualifiers(test.qualifiers); kept != test.expected {
+			t.Errorf("filterQualifiers(%q) = %q, expected %q", test.qualifiers, kept, test.expected)
+		}
+	}
+}
+
+func TestSemverLT(t *testing.T) {
+	tests := []struct {
+		v1       string
+		v2       string
+		expected bool
+	}{
+		{v1: "1.2.3", v2: "1.2.4", expected: true},
+		{v1: "1.2.4", v2: "1.2.3", expected: false},
+		{v1: "1.2.3-rc.1", v2: "1.2.3", expected: true},
+		{v1: "1.2.3", v2: "1.2.3", expected: false},
+		{v1: "1.2.3+4", v2: "1.2.3+5", expected: true},
+		{v1: "1.2.3+9", v2: "1.2.3+10", expected: true},
+		{v1: "1.2.3+10", v2: "1.2.3+9", expected: false},
+		{v1: "1.2.3", v2: "1.2.3+1", expected: true},
+		{v1: "1.2.3+a", v2: "1.2.3+b", expected: true},
+		{v1: "1.2.3+5", v2: "1.2.4+1", expected: true},
+	}
+	for _, test := range tests {
+		if lt := SemverLT(semver.MustParse(test.v1), semver.MustParse(test.v2)); lt != test.expected {
+			t.Errorf("SemverLT(%s, %s) = %t, expected %t", test.v1, test.v2, lt, test.expected)
+		}
+	}
+}
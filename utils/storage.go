@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
+)
+
+type StorageChecksums struct {
+	Sha1   string `json:"sha1"`
+	Md5    string `json:"md5"`
+	Sha256 string `json:"sha256"`
+}
+
+// StorageInfo is the file info given by artifactory storage api.
+type StorageInfo struct {
+	Repo         string           `json:"repo"`
+	Path         string           `json:"path"`
+	Created      string           `json:"created"`
+	CreatedBy    string           `json:"createdBy"`
+	LastModified string           `json:"lastModified"`
+	ModifiedBy   string           `json:"modifiedBy"`
+	DownloadUri  string           `json:"downloadUri"`
+	Size         string           `json:"size"`
+	Checksums    StorageChecksums `json:"checksums"`
+}
+
+func NewHttpClient(caCert string) (*http.Client, error) {
+	client := &http.Client{}
+	if caCert == "" {
+		return client, nil
+	}
+	caPool := x509.NewCertPool()
+	ok := caPool.AppendCertsFromPEM([]byte(caCert))
+	if !ok {
+		return nil, errors.New("Error parsing pem certificate")
+	}
+	client.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs: caPool,
+		},
+	}
+	return client, nil
+}
+
+// NewStorageRequest creates an authenticated request on artifactory storage api for a file path,
+// query is appended as is (e.g.: 'properties').
+func NewStorageRequest(artdetails *config.ServerDetails, filePath string, query string) (*http.Request, error) {
+	url := fmt.Sprintf("%sapi/storage/%s", artdetails.Url, RemoveStartingSlashIfNeeded(filePath))
+	if query != "" {
+		url += "?" + query
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	SetAuthentication(req, artdetails)
+	return req, nil
+}
+
+func RetrieveStorageInfo(artdetails *config.ServerDetails, caCert string, filePath string) (StorageInfo, error) {
+	info, found, err := FindStorageInfo(artdetails, caCert, filePath)
+	if err != nil {
+		return StorageInfo{}, err
+	}
+	if !found {
+		return StorageInfo{}, fmt.Errorf("Couldn't get storage info of '%s'. Response code: %d", filePath, http.StatusNotFound)
+	}
+	return info, nil
+}
+
+// FindStorageInfo gives the file info like RetrieveStorageInfo, found is false when file doesn't exist.
+func FindStorageInfo(artdetails *config.ServerDetails, caCert string, filePath string) (info StorageInfo, found bool, err error) {
+	client, err := NewHttpClient(caCert)
+	if err != nil {
+		return StorageInfo{}, false, err
+	}
+	req, err := NewStorageRequest(artdetails, filePath, "")
+	if err != nil {
+		return StorageInfo{}, false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return StorageInfo{}, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return StorageInfo{}, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return StorageInfo{}, false, fmt.Errorf("Couldn't get storage info of '%s'. Response code: %d", filePath, resp.StatusCode)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil {
+		return StorageInfo{}, false, err
+	}
+	return info, true, nil
+}
+
+// RetrieveStorageProperties gives properties of a file, artifactory answers not found when file has no properties.
+func RetrieveStorageProperties(artdetails *config.ServerDetails, caCert string, filePath string) (map[string][]string, error) {
+	client, err := NewHttpClient(caCert)
+	if err != nil {
+		return nil, err
+	}
+	req, err := NewStorageRequest(artdetails, filePath, "properties")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string][]string{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Couldn't get properties of '%s'. Response code: %d", filePath, resp.StatusCode)
+	}
+	var propsResp struct {
+		Properties map[string][]string `json:"properties"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&propsResp)
+	if err != nil {
+		return nil, err
+	}
+	if propsResp.Properties == nil {
+		return map[string][]string{}, nil
+	}
+	return propsResp.Properties, nil
+}
+
+// RetrieveFileContent gives content of a file stored in artifactory, found is false when file doesn't exist.
+func RetrieveFileContent(artdetails *config.ServerDetails, caCert string, filePath string) (content []byte, found bool, err error) {
+	client, err := NewHttpClient(caCert)
+	if err != nil {
+		return nil, false, err
+	}
+	req, err := http.NewRequest("GET", artdetails.Url+RemoveStartingSlashIfNeeded(filePath), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	SetAuthentication(req, artdetails)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("Couldn't get file '%s'. Response code: %d", filePath, resp.StatusCode)
+	}
+	content, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	return content, true, nil
+}
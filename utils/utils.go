@@ -1,12 +1,16 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
+	chelper "github.com/ArthurHlt/go-concourse-helper"
 	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
 	"github.com/jfrog/jfrog-cli-core/v2/utils/coreutils"
 	artlog "github.com/jfrog/jfrog-client-go/utils/log"
@@ -17,6 +21,21 @@ const (
 	ART_SECURITY_FOLDER = "security/"
 )
 
+// NewMessager creates a concourse messager and gives back the full version sent by concourse.
+// Concourse helper only keeps the build field of a version, request is so read first and replayed to the messager.
+func NewMessager() (*chelper.Messager, model.Version) {
+	msg := chelper.NewMessager()
+	raw, err := ioutil.ReadAll(msg.RequestReader)
+	msg.FatalIf("Error when reading request given by concourse", err)
+	var request struct {
+		Version model.Version `json:"version"`
+	}
+	err = json.Unmarshal(raw, &request)
+	msg.FatalIf("Error when parsing object given by concourse", err)
+	msg.RequestReader = bytes.NewReader(raw)
+	return msg, request.Version
+}
+
 func CheckReqParamsWithPattern(source model.Source) error {
 	if source.Pattern == "" {
 		return errors.New("You must provide a pattern for your file (e.g.: 'local_generic/myfile.txt','local_generic/my*.txt'.")
@@ -28,28 +47,79 @@ func CheckReqParams(source model.Source) error {
 	if source.Url == "" {
 		return errors.New("You must pass an url to artifactory.")
 	}
-	if source.User == "" && source.ApiKey == "" {
-		return errors.New("You must pass user/password pair or apiKey to authnticate over artifactory.")
+	if source.ApiKey != "" && source.LegacyApiKey != "" {
+		return errors.New("You must pass only one of api_key or apiKey (apiKey is deprecated, use api_key).")
+	}
+	apiKey := RetrieveApiKey(source)
+	nbCreds := 0
+	for _, cred := range []string{source.Password, apiKey, source.AccessToken} {
+		if cred != "" {
+			nbCreds++
+		}
+	}
+	if nbCreds > 1 {
+		return errors.New("You must pass only one of password, api_key or access_token to authenticate over artifactory.")
+	}
+	if source.AccessToken != "" {
+		return nil
+	}
+	if apiKey != "" && source.User == "" {
+		return errors.New("You must pass an user with api_key to authenticate over artifactory.")
+	}
+	if source.User == "" {
+		return errors.New("You must pass user/password pair, user/api_key pair or access_token to authenticate over artifactory.")
 	}
 	return nil
 }
 
+// RetrieveApiKey gives the api key set in source, falling back to deprecated apiKey field.
+func RetrieveApiKey(source model.Source) string {
+	if source.ApiKey != "" {
+		return source.ApiKey
+	}
+	return source.LegacyApiKey
+}
+
 func RetrieveArtDetails(source model.Source) (*config.ServerDetails, error) {
 	err := createCert(source.CACert)
 	if err != nil {
 		return nil, err
 	}
 	sshKeyPath, err := createSshKeyPath(source.SshKey)
+	if err != nil {
+		return nil, err
+	}
+	// artifactory accepts an api key in place of the password when used with basic auth
+	password := source.Password
+	if apiKey := RetrieveApiKey(source); apiKey != "" {
+		password = apiKey
+	}
 	return &config.ServerDetails{
 		ArtifactoryUrl: AddTrailingSlashIfNeeded(source.Url),
 		Url:            AddTrailingSlashIfNeeded(source.Url),
 		User:           source.User,
-		Password:       source.Password,
+		Password:       password,
+		AccessToken:    source.AccessToken,
 		SshKeyPath:     sshKeyPath,
 	}, nil
 
 }
 
+// SetAuthentication sets on request the same authentication as jfrog client would do for these details.
+func SetAuthentication(req *http.Request, artdetails *config.ServerDetails) {
+	if artdetails.AccessToken != "" {
+		if artdetails.User != "" {
+			req.SetBasicAuth(artdetails.User, artdetails.AccessToken)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+artdetails.AccessToken)
+		}
+		return
+	}
+	if artdetails.Password != "" {
+		req.SetBasicAuth(artdetails.User, artdetails.Password)
+	}
+}
+
 func AddTrailingSlashIfNeeded(path string) string {
 	if path != "" && !strings.HasSuffix(path, "/") {
 		path += "/"